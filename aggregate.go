@@ -0,0 +1,478 @@
+package sqlice
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// aggregateFuncs are the supported aggregate functions
+var aggregateFuncs = map[string]bool{"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true}
+
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// Aggregate filters the input slice, groups the matching elements by the groupBy columns, and stores a row per
+// group holding the selected columns in output, like "SELECT columns... GROUP BY groupBy...". The columns may
+// use the COUNT, SUM, AVG, MIN and MAX aggregate functions, which ignore NULLs like in SQL, and any other
+// column must be grouped by. Without groupBy columns, the aggregates are computed over all matching elements.
+// Aggregates are named like in Postgres, e.g. "count" for COUNT(*), unless they're given an alias. Output
+// must be a pointer to a slice of map[string]interface{} or of a struct, like for Project
+func Aggregate(input, output interface{}, filter squirrel.Sqlizer, groupBy []string, columns ...string) error {
+	return defaultFilterer.Aggregate(input, output, filter, groupBy, columns...)
+}
+
+// Aggregate groups the filtered input slice and stores the selected columns of each group in output. See the
+// package level Aggregate for details
+func (f *Filterer) Aggregate(input, output interface{}, filter squirrel.Sqlizer, groupBy []string, columns ...string) error {
+	inVal, outVal, err := getProjectionValues(input, output)
+	if err != nil {
+		return fmt.Errorf("failed to validate in/out params: %w", err)
+	}
+	if len(columns) == 0 {
+		return errors.New("no columns selected")
+	}
+	plan := &selectPlan{}
+	if plan.columns, err = parseSelectList(strings.Join(columns, ", "), nil); err != nil {
+		return fmt.Errorf("unable to parse columns: %w", err)
+	}
+	if len(groupBy) > 0 {
+		if plan.groupBy, err = parseExpressionList(strings.Join(groupBy, ", ")); err != nil {
+			return fmt.Errorf("unable to parse group by: %w", err)
+		}
+	}
+	result, err := f.filter(inVal, filter)
+	if err != nil {
+		return err
+	}
+	if result, err = f.execSelect(result, plan, outVal.Type().Elem()); err != nil {
+		return err
+	}
+	outVal.Set(result)
+	return nil
+}
+
+// parseExpressionList parses comma separated expressions, such as the columns of a GROUP BY clause
+func parseExpressionList(sql string) ([]exprNode, error) {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	nodes, err := p.parseExpressions()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func (p *parser) parseExpressions() ([]exprNode, error) {
+	var nodes []exprNode
+	for {
+		node, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		if !p.acceptSymbol(",") {
+			return nodes, nil
+		}
+	}
+}
+
+// isAggregateCall reports whether node is a call to an aggregate function
+func isAggregateCall(node exprNode) bool {
+	call, ok := node.(funcNode)
+	return ok && aggregateFuncs[call.name]
+}
+
+// containsAggregate reports whether any of the nodes calls an aggregate function
+func containsAggregate(nodes ...exprNode) bool {
+	found := false
+	for _, node := range nodes {
+		_, _ = rewriteNode(node, func(n exprNode) (exprNode, bool, error) {
+			found = found || isAggregateCall(n)
+			return n, found, nil
+		})
+	}
+	return found
+}
+
+// aggregateColumn is the name of the grouped row column holding the ith aggregate. It can't clash with the
+// name of a grouped column, since it isn't a valid SQL identifier
+func aggregateColumn(i int) string {
+	return "#" + strconv.Itoa(i)
+}
+
+// grouping groups rows into rows of an intermediate struct type, holding the grouped columns followed by the
+// results of the aggregates
+type grouping struct {
+	rowType    reflect.Type
+	groupBy    []fieldInfo
+	aggregates []aggregate
+}
+
+// aggregate is a compiled aggregate function call
+type aggregate struct {
+	call funcNode
+	// arg is nil for COUNT(*)
+	arg evaluator
+}
+
+// groupingPlanner rewrites the expressions evaluated after grouping to use the columns of the grouped rows,
+// collecting the aggregates they use
+type groupingPlanner struct {
+	f          *Filterer
+	fields     map[string]fieldInfo
+	groupNames []string
+	aggregates []funcNode
+}
+
+// compileGrouping compiles grouping rows with the given fields by the plan's GROUP BY columns. It returns a copy
+// of the plan with the expressions evaluated after grouping rewritten to be evaluated against the grouped rows
+func (f *Filterer) compileGrouping(fields map[string]fieldInfo, plan *selectPlan) (*grouping, *selectPlan, error) {
+	g := &groupingPlanner{f: f, fields: fields}
+	for _, node := range plan.groupBy {
+		column, ok := node.(columnNode)
+		if !ok {
+			return nil, nil, errors.New("only columns can be grouped by")
+		}
+		name := f.normalizeName(column.name)
+		if _, ok := fields[name]; !ok {
+			return nil, nil, fmt.Errorf("struct has no field named '%v'", column.name)
+		}
+		g.groupNames = append(g.groupNames, name)
+	}
+
+	rewritten := *plan
+	rewritten.columns = make([]selectColumn, len(plan.columns))
+	for i, column := range plan.columns {
+		if column.expr == nil {
+			return nil, nil, errors.New("cannot select * from grouped rows")
+		}
+		expr, err := g.rewrite(column.expr)
+		if err != nil {
+			return nil, nil, err
+		}
+		rewritten.columns[i] = selectColumn{expr: expr, name: column.name}
+	}
+	rewritten.orderBy = make([]orderNode, len(plan.orderBy))
+	for i, term := range plan.orderBy {
+		expr, err := g.rewrite(term.expr)
+		if err != nil {
+			return nil, nil, err
+		}
+		term.expr = expr
+		rewritten.orderBy[i] = term
+	}
+	if plan.having != nil {
+		having, err := g.rewrite(plan.having)
+		if err != nil {
+			return nil, nil, err
+		}
+		rewritten.having = having
+	}
+
+	grp, err := g.build()
+	return grp, &rewritten, err
+}
+
+// rewrite replaces the aggregates in node with references to the grouped row columns holding their results
+func (g *groupingPlanner) rewrite(node exprNode) (exprNode, error) {
+	return rewriteNode(node, func(n exprNode) (exprNode, bool, error) {
+		switch n := n.(type) {
+		case funcNode:
+			if !aggregateFuncs[n.name] {
+				return nil, false, nil
+			}
+			if containsAggregate(n.args...) {
+				return nil, false, errors.New("aggregate function calls cannot be nested")
+			}
+			for i, existing := range g.aggregates {
+				if reflect.DeepEqual(existing, n) {
+					return columnNode{name: aggregateColumn(i)}, true, nil
+				}
+			}
+			g.aggregates = append(g.aggregates, n)
+			return columnNode{name: aggregateColumn(len(g.aggregates) - 1)}, true, nil
+		case columnNode:
+			name := g.f.normalizeName(n.name)
+			for _, groupName := range g.groupNames {
+				if name == groupName {
+					return columnNode{name: name}, true, nil
+				}
+			}
+			if _, ok := g.fields[name]; !ok {
+				return nil, false, fmt.Errorf("struct has no field named '%v'", n.name)
+			}
+			return nil, false, fmt.Errorf("column '%v' must be grouped by or used in an aggregate function", n.name)
+		default:
+			return nil, false, nil
+		}
+	})
+}
+
+// build compiles the aggregates and creates the grouped row type
+func (g *groupingPlanner) build() (*grouping, error) {
+	grp := &grouping{}
+	rowFields := make([]reflect.StructField, 0, len(g.groupNames)+len(g.aggregates))
+	for i, name := range g.groupNames {
+		field := g.fields[name]
+		grp.groupBy = append(grp.groupBy, field)
+		rowFields = append(rowFields, reflect.StructField{
+			Name: "G" + strconv.Itoa(i),
+			Type: field.Type,
+			Tag:  reflect.StructTag(g.f.tagName + ":" + strconv.Quote(name)),
+		})
+	}
+	for i, call := range g.aggregates {
+		agg := aggregate{call: call}
+		switch {
+		case call.star && call.name != "COUNT":
+			return nil, fmt.Errorf("%v(*) is not supported", call.name)
+		case !call.star && len(call.args) != 1:
+			return nil, fmt.Errorf("%v takes exactly one argument", call.name)
+		case !call.star:
+			arg, err := g.f.compileNode(call.args[0], g.fields)
+			if err != nil {
+				return nil, err
+			}
+			agg.arg = arg
+		}
+		grp.aggregates = append(grp.aggregates, agg)
+		rowFields = append(rowFields, reflect.StructField{
+			Name: "A" + strconv.Itoa(i),
+			Type: g.resultType(call),
+			Tag:  reflect.StructTag(g.f.tagName + ":" + strconv.Quote(aggregateColumn(i))),
+		})
+	}
+	grp.rowType = reflect.StructOf(rowFields)
+	return grp, nil
+}
+
+// resultType returns the type of the grouped row column holding the result of the aggregate, so filters on it
+// can be validated. It's a pointer unless the result can't be NULL, and the empty interface if the type of the
+// result isn't known until rows are aggregated
+func (g *groupingPlanner) resultType(call funcNode) reflect.Type {
+	switch call.name {
+	case "COUNT":
+		return reflect.TypeOf(int64(0))
+	case "AVG":
+		return reflect.TypeOf((*float64)(nil))
+	}
+	column, ok := call.args[0].(columnNode)
+	if !ok {
+		return interfaceType
+	}
+	argType := g.fields[g.f.normalizeName(column.name)].Type
+	for argType.Kind() == reflect.Ptr {
+		argType = argType.Elem()
+	}
+	if isValuer(argType) {
+		return interfaceType
+	}
+	if call.name != "SUM" {
+		return reflect.PtrTo(argType)
+	}
+	switch reducedKind(argType.Kind()) {
+	case reflect.Int64:
+		return reflect.TypeOf((*int64)(nil))
+	case reflect.Uint64:
+		return reflect.TypeOf((*uint64)(nil))
+	case reflect.Float64:
+		return reflect.TypeOf((*float64)(nil))
+	default:
+		return interfaceType
+	}
+}
+
+// apply returns a slice of the grouped rows of inVal, in the order their groups first appear. If nothing is
+// grouped by, there is always a single row
+func (g *grouping) apply(inVal reflect.Value) (reflect.Value, error) {
+	type group struct {
+		first        reflect.Value
+		accumulators []*accumulator
+	}
+	newGroup := func(first reflect.Value) *group {
+		grp := &group{first: first}
+		for _, agg := range g.aggregates {
+			grp.accumulators = append(grp.accumulators, newAccumulator(agg.call))
+		}
+		return grp
+	}
+
+	var groups []*group
+	groupsByKey := make(map[string]*group)
+	keyValues := make([]interface{}, len(g.groupBy))
+	for i := 0; i < inVal.Len(); i++ {
+		item := inVal.Index(i)
+		for j, field := range g.groupBy {
+			value, ok, err := fieldValue(item, field)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("unable to group: %w", err)
+			}
+			keyValues[j] = nil
+			if ok {
+				keyValues[j] = value.Interface()
+			}
+		}
+		key := groupKey(keyValues)
+		grp, ok := groupsByKey[key]
+		if !ok {
+			grp = newGroup(item)
+			groupsByKey[key] = grp
+			groups = append(groups, grp)
+		}
+		for j, agg := range g.aggregates {
+			var value interface{} = 1
+			if agg.arg != nil {
+				var err error
+				if value, err = agg.arg(item); err != nil {
+					return reflect.Value{}, fmt.Errorf("unable to aggregate: %w", err)
+				}
+			}
+			if err := grp.accumulators[j].add(value); err != nil {
+				return reflect.Value{}, fmt.Errorf("unable to aggregate: %w", err)
+			}
+		}
+	}
+	if len(g.groupBy) == 0 && len(groups) == 0 {
+		groups = append(groups, newGroup(reflect.Value{}))
+	}
+
+	outVal := reflect.MakeSlice(reflect.SliceOf(g.rowType), len(groups), len(groups))
+	for i, grp := range groups {
+		row := outVal.Index(i)
+		for j, field := range g.groupBy {
+			if value, ok := fieldByIndex(grp.first, field.Index); ok {
+				row.Field(j).Set(value)
+			}
+		}
+		for j, acc := range grp.accumulators {
+			if err := assignValue(row.Field(len(g.groupBy)+j), acc.result()); err != nil {
+				return reflect.Value{}, fmt.Errorf("unable to aggregate: %w", err)
+			}
+		}
+	}
+	return outVal, nil
+}
+
+// groupKey returns a string identifying the combination of values. NULLs are grouped together, like in SQL
+func groupKey(values []interface{}) string {
+	var b strings.Builder
+	for _, value := range values {
+		if t, ok := value.(time.Time); ok {
+			// equal times in different locations are the same value
+			value = t.UTC()
+		}
+		fmt.Fprintf(&b, "%#v|", value)
+	}
+	return b.String()
+}
+
+// accumulator computes an aggregate function over the values it's given, ignoring NULLs
+type accumulator struct {
+	call  funcNode
+	count int64
+	// value is the running SUM, MIN or MAX
+	value interface{}
+	// seen holds the keys of the values added, for DISTINCT
+	seen map[string]bool
+}
+
+func newAccumulator(call funcNode) *accumulator {
+	acc := &accumulator{call: call}
+	if call.distinct {
+		acc.seen = make(map[string]bool)
+	}
+	return acc
+}
+
+func (a *accumulator) add(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	if a.seen != nil {
+		key := groupKey([]interface{}{value})
+		if a.seen[key] {
+			return nil
+		}
+		a.seen[key] = true
+	}
+	a.count++
+	if a.call.name == "COUNT" {
+		return nil
+	}
+	if a.value == nil {
+		if a.call.name == "SUM" || a.call.name == "AVG" {
+			a.value = reflect.Zero(reflect.TypeOf(value)).Interface()
+			if !isNumberKind(reflect.TypeOf(value).Kind()) {
+				return fmt.Errorf("cannot %v values of type %T", a.call.name, value)
+			}
+		} else {
+			a.value = value
+			return nil
+		}
+	}
+
+	switch a.call.name {
+	case "SUM", "AVG":
+		sum, err := addNumbers(a.value, value)
+		if err != nil {
+			return fmt.Errorf("cannot %v: %w", a.call.name, err)
+		}
+		a.value = sum
+	case "MIN", "MAX":
+		op := opLT
+		if a.call.name == "MAX" {
+			op = opGT
+		}
+		better, ok := sqlCompare(value, a.value, op)
+		if !ok {
+			return fmt.Errorf("cannot %v values of types %T and %T", a.call.name, a.value, value)
+		}
+		if better {
+			a.value = value
+		}
+	}
+	return nil
+}
+
+// result returns the value of the aggregate. Like in SQL, COUNT is 0 if no values were added, and the other
+// aggregates are NULL
+func (a *accumulator) result() interface{} {
+	switch a.call.name {
+	case "COUNT":
+		return a.count
+	case "AVG":
+		if a.count == 0 {
+			return nil
+		}
+		return toFloat(reflect.ValueOf(a.value)) / float64(a.count)
+	default:
+		return a.value
+	}
+}
+
+// addNumbers returns a + b. Integers are summed as int64 or uint64, and other numbers as float64
+func addNumbers(a, b interface{}) (interface{}, error) {
+	v1, v2 := coerceNumbers(reflect.ValueOf(a), reflect.ValueOf(b))
+	if !isNumberKind(v2.Kind()) {
+		return nil, fmt.Errorf("%T is not a number", b)
+	}
+	switch reducedKind(v1.Kind()) {
+	case reflect.Int64:
+		return v1.Int() + v2.Int(), nil
+	case reflect.Uint64:
+		return v1.Uint() + v2.Uint(), nil
+	default:
+		return v1.Float() + v2.Float(), nil
+	}
+}
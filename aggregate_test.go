@@ -0,0 +1,274 @@
+package sqlice_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+func TestAggregate(t *testing.T) {
+	tests := map[string]struct {
+		filter         squirrel.Sqlizer
+		groupBy        []string
+		columns        []string
+		expectedOutput []map[string]interface{}
+	}{
+		"count": {
+			columns:        []string{"COUNT(*)"},
+			expectedOutput: []map[string]interface{}{{"count": int64(5)}},
+		},
+		"group by": {
+			groupBy: []string{"status"},
+			columns: []string{"status", "COUNT(*)", "SUM(amount) AS total"},
+			expectedOutput: []map[string]interface{}{
+				{"status": "paid", "count": int64(3), "total": float64(45)},
+				{"status": "pending", "count": int64(1), "total": float64(20)},
+				{"status": "refunded", "count": int64(1), "total": float64(15)},
+			},
+		},
+		"multiple columns": {
+			filter:  squirrel.Eq{"status": "paid"},
+			groupBy: []string{"status", "name"},
+			columns: []string{"name", "MAX(amount)", "MIN(id)"},
+			expectedOutput: []map[string]interface{}{
+				{"name": "alice", "max": float64(30), "min": 1},
+				{"name": "carol", "max": float64(5), "min": 4},
+			},
+		},
+		"nulls ignored": {
+			groupBy: []string{"status"},
+			columns: []string{"status", "COUNT(discount)", "SUM(discount)", "AVG(discount)", "MIN(discount)"},
+			expectedOutput: []map[string]interface{}{
+				{"status": "paid", "count": int64(2), "sum": int64(15), "avg": 7.5, "min": 5},
+				{"status": "pending", "count": int64(0), "sum": nil, "avg": nil, "min": nil},
+				{"status": "refunded", "count": int64(1), "sum": int64(5), "avg": float64(5), "min": 5},
+			},
+		},
+		"distinct": {
+			columns:        []string{"COUNT(DISTINCT name) names", "COUNT(name)"},
+			expectedOutput: []map[string]interface{}{{"names": int64(3), "count": int64(5)}},
+		},
+		"expressions": {
+			groupBy: []string{"name"},
+			columns: []string{"name", "COUNT(*) > 1 AS repeat", "MAX(amount) = MIN(amount) AS flat"},
+			expectedOutput: []map[string]interface{}{
+				{"name": "alice", "repeat": true, "flat": false},
+				{"name": "bob", "repeat": true, "flat": false},
+				{"name": "carol", "repeat": false, "flat": true},
+			},
+		},
+		"no rows": {
+			filter:         squirrel.Eq{"status": "shipped"},
+			columns:        []string{"COUNT(*)", "SUM(amount)"},
+			expectedOutput: []map[string]interface{}{{"count": int64(0), "sum": nil}},
+		},
+		"no groups": {
+			filter:         squirrel.Eq{"status": "shipped"},
+			groupBy:        []string{"status"},
+			columns:        []string{"COUNT(*)"},
+			expectedOutput: []map[string]interface{}{},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []map[string]interface{}
+			err := sqlice.Aggregate(records(), &output, test.filter, test.groupBy, test.columns...)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if !reflect.DeepEqual(output, test.expectedOutput) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestAggregate_ErrorConditions(t *testing.T) {
+	tests := map[string]struct {
+		groupBy []string
+		columns []string
+	}{
+		"no columns":        {columns: nil},
+		"ungrouped column":  {groupBy: []string{"status"}, columns: []string{"name"}},
+		"star":              {groupBy: []string{"status"}, columns: []string{"*"}},
+		"unknown group":     {groupBy: []string{"foo"}, columns: []string{"COUNT(*)"}},
+		"group expression":  {groupBy: []string{"id > 1"}, columns: []string{"COUNT(*)"}},
+		"unknown column":    {columns: []string{"SUM(foo)"}},
+		"nested aggregates": {columns: []string{"SUM(COUNT(*))"}},
+		"sum star":          {columns: []string{"SUM(*)"}},
+		"too many args":     {columns: []string{"MAX(id, amount)"}},
+		"sum strings":       {columns: []string{"SUM(status)"}},
+		"unknown function":  {columns: []string{"LOWER(status)"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []map[string]interface{}
+			err := sqlice.Aggregate(records(), &output, nil, test.groupBy, test.columns...)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestAggregate_Structs(t *testing.T) {
+	type average struct {
+		Status string
+		Avg    int
+	}
+	var output []average
+	err := sqlice.Aggregate(records(), &output, squirrel.Eq{"status": "refunded"}, []string{"status"}, "status", "AVG(discount)")
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if expected := []average{{Status: "refunded", Avg: 5}}; !reflect.DeepEqual(output, expected) {
+		t.Errorf("Expected '%v' got '%v'", expected, output)
+	}
+	// the average of the paid orders' discounts is 7.5, which can't be stored in an int
+	err = sqlice.Aggregate(records(), &output, squirrel.Eq{"status": "paid"}, []string{"status"}, "status", "AVG(discount)")
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+}
+
+func TestSelect_GroupBy(t *testing.T) {
+	type summary struct {
+		Name   string
+		Orders int
+		Total  float64
+	}
+	query := squirrel.Select("name", "COUNT(*) AS orders", "SUM(amount) AS total").
+		From("orders").
+		Where(squirrel.NotEq{"status": "refunded"}).
+		GroupBy("name").
+		OrderBy("total DESC").
+		Limit(2)
+	var output []summary
+	err := sqlice.Select(records(), &output, query)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expectedOutput := []summary{{Name: "alice", Orders: 2, Total: 40}, {Name: "bob", Orders: 1, Total: 20}}
+	if !reflect.DeepEqual(output, expectedOutput) {
+		t.Errorf("Expected '%v' got '%v'", expectedOutput, output)
+	}
+
+	// aggregates in ORDER BY and WHERE
+	query = squirrel.Select("status").From("orders").GroupBy("status").OrderBy("COUNT(*) DESC, status")
+	var statuses []map[string]interface{}
+	if err := sqlice.Select(records(), &statuses, query); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expectedStatuses := []map[string]interface{}{{"status": "paid"}, {"status": "pending"}, {"status": "refunded"}}
+	if !reflect.DeepEqual(statuses, expectedStatuses) {
+		t.Errorf("Expected '%v' got '%v'", expectedStatuses, statuses)
+	}
+	err = sqlice.Select(records(), &statuses, squirrel.Select("status").From("orders").Where("COUNT(*) > 1"))
+	if err == nil {
+		t.Error("Expected an error for an aggregate in WHERE, got nil")
+	}
+}
+
+func TestSelect_Having(t *testing.T) {
+	base := squirrel.Select("name", "COUNT(*)", "SUM(amount) AS total").From("orders").GroupBy("name").OrderBy("name")
+
+	tests := map[string]struct {
+		query          squirrel.SelectBuilder
+		expectedOutput []map[string]interface{}
+	}{
+		"aggregate": {
+			query: base.Having("COUNT(*) > ?", 1),
+			expectedOutput: []map[string]interface{}{
+				{"name": "alice", "count": int64(2), "total": float64(40)},
+				{"name": "bob", "count": int64(2), "total": float64(35)},
+			},
+		},
+		"default name": {
+			query: base.Having(squirrel.Eq{"count": 1}),
+			expectedOutput: []map[string]interface{}{
+				{"name": "carol", "count": int64(1), "total": float64(5)},
+			},
+		},
+		"alias": {
+			query: base.Having(squirrel.Gt{"total": 35.0}),
+			expectedOutput: []map[string]interface{}{
+				{"name": "alice", "count": int64(2), "total": float64(40)},
+			},
+		},
+		"grouped column": {
+			query: base.Having(squirrel.Like{"name": "%o%"}),
+			expectedOutput: []map[string]interface{}{
+				{"name": "bob", "count": int64(2), "total": float64(35)},
+				{"name": "carol", "count": int64(1), "total": float64(5)},
+			},
+		},
+		"unselected aggregate": {
+			query: base.Having("MAX(amount) >= ?", 20).Having(squirrel.Lt{"total": 40.0}),
+			expectedOutput: []map[string]interface{}{
+				{"name": "bob", "count": int64(2), "total": float64(35)},
+			},
+		},
+		"without group by": {
+			query:          squirrel.Select("COUNT(*)").From("orders").Having("SUM(amount) > 1000"),
+			expectedOutput: []map[string]interface{}{},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []map[string]interface{}
+			err := sqlice.Select(records(), &output, test.query)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if !reflect.DeepEqual(output, test.expectedOutput) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestSelect_HavingErrorConditions(t *testing.T) {
+	base := squirrel.Select("name", "COUNT(*)").From("orders").GroupBy("name")
+	tests := map[string]struct {
+		query squirrel.SelectBuilder
+	}{
+		"ungrouped column": {query: base.Having(squirrel.Eq{"status": "paid"})},
+		"unknown column":   {query: base.Having(squirrel.Eq{"foo": 1})},
+		"invalid":          {query: base.Having("COUNT(*) >")},
+		"type mismatch":    {query: base.Having(squirrel.Gt{"count": "5"})},
+		"value filter":     {query: base.Having(sqlice.ValueFilterFunc(func(interface{}) bool { return true }))},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []map[string]interface{}
+			err := sqlice.Select(records(), &output, test.query)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func ExampleAggregate() {
+	type Order struct {
+		Status string
+		Amount float64
+	}
+	type StatusTotal struct {
+		Status string
+		Count  int
+		Total  float64
+	}
+	input := []Order{{Status: "paid", Amount: 10}, {Status: "pending", Amount: 20}, {Status: "paid", Amount: 30}}
+	var output []StatusTotal
+
+	err := sqlice.Aggregate(input, &output, nil, []string{"status"}, "status", "COUNT(*)", "SUM(amount) AS total")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(output)
+	// Output: [{paid 2 40} {pending 1 20}]
+}
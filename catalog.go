@@ -0,0 +1,210 @@
+package sqlice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Catalog is a registry of slices used as tables, keyed by their table names. Filterers configured
+// WithCatalog run subqueries against its tables, and the catalog itself runs SELECT queries, implementing
+// squirrel's runner interfaces so queries can be run with RunWith(catalog). Catalogs are safe for concurrent
+// use
+type Catalog struct {
+	mu     sync.RWMutex
+	tables map[string]reflect.Value
+
+	filterer *Filterer
+	// db is opened by the first query, since every *sql.DB runs a goroutine until it's closed
+	dbOnce sync.Once
+	db     *sql.DB
+}
+
+// NewCatalog creates an empty Catalog. The options configure the Filterer running its queries
+func NewCatalog(opts ...Option) *Catalog {
+	c := &Catalog{tables: make(map[string]reflect.Value)}
+	c.filterer = NewFilterer(append(opts, WithCatalog(c))...)
+	return c
+}
+
+// Register adds the slice rows to the catalog as the table name, replacing any table of the same name. Table
+// names are case insensitive. Rows must be a slice of structs or a pointer to one, and is used as it is, so later
+// changes to its elements are seen by queries. Register a pointer for queries to also see elements added or
+// removed later, e.g. by append or Delete
+func (c *Catalog) Register(name string, rows interface{}) error {
+	if name == "" {
+		return errors.New("table name is empty")
+	}
+	var inVal reflect.Value
+	var err error
+	if rows != nil && reflect.TypeOf(rows).Kind() == reflect.Ptr {
+		// the slice the pointer references is read by each query, so it sees the slice's current length
+		inVal, err = getSliceValue(rows)
+	} else {
+		inVal, err = getInputValue(rows)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to use table '%v': %w", name, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables[strings.ToLower(name)] = inVal
+	return nil
+}
+
+// table returns the rows of the table name
+func (c *Catalog) table(name string) (reflect.Value, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	rows, ok := c.tables[strings.ToLower(name)]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("catalog has no table named '%v'", name)
+	}
+	return rows, nil
+}
+
+// newSubquery converts a SelectBuilder used as a value into a subqueryNode
+func newSubquery(query squirrel.SelectBuilder) (subqueryNode, error) {
+	table, plan, err := planSelect(query)
+	if err != nil {
+		return subqueryNode{}, err
+	}
+	if table == "" {
+		return subqueryNode{}, errors.New("subquery has no FROM clause")
+	}
+	return subqueryNode{table: table, plan: plan}, nil
+}
+
+// subqueryValues runs the subquery against the tables of the Filterer's catalog, returning the values of the
+// single column it selects
+func (f *Filterer) subqueryValues(subquery subqueryNode) ([]interface{}, error) {
+	if f.catalog == nil {
+		return nil, errors.New("subqueries require a Filterer configured WithCatalog")
+	}
+	rows, err := f.catalog.table(subquery.table)
+	if err != nil {
+		return nil, fmt.Errorf("unable to use subquery: %w", err)
+	}
+	plan := subquery.plan
+	if len(plan.columns) != 1 || plan.columns[0].expr == nil {
+		return nil, errors.New("subquery must select a single column")
+	}
+	result, err := f.execSelect(rows, plan, mapRowType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to run subquery: %w", err)
+	}
+	name := plan.columns[0].name
+	values := make([]interface{}, result.Len())
+	for i := range values {
+		values[i] = result.Index(i).Interface().(map[string]interface{})[name]
+	}
+	return values, nil
+}
+
+// DB returns a *sql.DB running queries against the catalog's tables, for code using database/sql directly. See
+// Driver for what it supports
+func (c *Catalog) DB() *sql.DB {
+	c.dbOnce.Do(func() {
+		c.db = sql.OpenDB(connector{catalog: c})
+	})
+	return c.db
+}
+
+// Close closes the catalog's *sql.DB, stopping its goroutines. Queries run after Close return an error. Catalogs
+// that never ran a query don't need to be closed
+func (c *Catalog) Close() error {
+	return c.DB().Close()
+}
+
+// Query runs the SELECT query against the catalog's tables. The rows hold the selected columns, converted to
+// driver values like a database would return them
+func (c *Catalog) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.DB().Query(query, args...)
+}
+
+// QueryContext is Query with a context
+func (c *Catalog) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.DB().QueryContext(ctx, query, args...)
+}
+
+// QueryRow runs the SELECT query against the catalog's tables, returning its first row as a *sql.Row
+func (c *Catalog) QueryRow(query string, args ...interface{}) squirrel.RowScanner {
+	return c.DB().QueryRow(query, args...)
+}
+
+// QueryRowContext is QueryRow with a context
+func (c *Catalog) QueryRowContext(ctx context.Context, query string, args ...interface{}) squirrel.RowScanner {
+	return c.DB().QueryRowContext(ctx, query, args...)
+}
+
+// Exec returns an error, since only SELECT queries are supported
+func (c *Catalog) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.DB().Exec(query, args...)
+}
+
+// ExecContext is Exec with a context
+func (c *Catalog) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.DB().ExecContext(ctx, query, args...)
+}
+
+// query parses and runs a SELECT statement
+func (c *Catalog) query(query string, args []interface{}) (*rows, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse query: %w", err)
+	}
+	p := &parser{tokens: tokens, args: args}
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, fmt.Errorf("unable to parse query: %w", err)
+	}
+	table, plan, err := p.parseSelect()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse query: %w", err)
+	}
+	p.acceptSymbol(";")
+	if err := p.expectEnd(); err != nil {
+		return nil, fmt.Errorf("unable to parse query: %w", err)
+	}
+
+	inVal, err := c.table(table)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.columns) == 0 {
+		plan.columns = []selectColumn{{name: "*"}}
+	}
+	compiled, err := c.filterer.compileSelect(inVal.Type().Elem(), plan, mapRowType)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := compiled.rows(inVal)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &rows{columns: make([]string, len(compiled.proj.columns))}
+	for i, column := range compiled.proj.columns {
+		result.columns[i] = column.name
+	}
+	for i := 0; i < selected.Len(); i++ {
+		values, err := compiled.proj.values(selected.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		row := make([]driver.Value, len(values))
+		for j, value := range values {
+			if row[j], err = driver.DefaultParameterConverter.ConvertValue(value); err != nil {
+				return nil, fmt.Errorf("unable to convert column '%v': %w", result.columns[j], err)
+			}
+		}
+		result.values = append(result.values, row)
+	}
+	return result, nil
+}
@@ -0,0 +1,405 @@
+package sqlice_test
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+func newJoinCatalog(t *testing.T) *sqlice.Catalog {
+	users, orders := joinTables()
+	catalog := sqlice.NewCatalog()
+	t.Cleanup(func() { catalog.Close() })
+	if err := catalog.Register("users", users.Rows); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if err := catalog.Register("Orders", orders.Rows); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	return catalog
+}
+
+func TestCatalog_Subqueries(t *testing.T) {
+	bigOrders := squirrel.Select("user_id").From("orders").Where(squirrel.GtOrEq{"amount": 15.0})
+	tests := map[string]struct {
+		filter      squirrel.Sqlizer
+		expectedIDs []int
+	}{
+		"eq": {
+			filter:      squirrel.Eq{"id": squirrel.Select("user_id").From("orders")},
+			expectedIDs: []int{1, 2},
+		},
+		"not eq": {
+			filter:      squirrel.NotEq{"id": squirrel.Select("user_id").From("orders")},
+			expectedIDs: []int{3},
+		},
+		"filtered subquery": {
+			filter:      squirrel.Eq{"id": bigOrders},
+			expectedIDs: []int{1, 2},
+		},
+		"limited subquery": {
+			filter:      squirrel.Eq{"id": squirrel.Select("user_id").From("orders").OrderBy("amount DESC").Limit(1)},
+			expectedIDs: []int{1},
+		},
+		"empty subquery": {
+			filter:      squirrel.Eq{"id": squirrel.Select("user_id").From("orders").Where("amount > 100")},
+			expectedIDs: []int{},
+		},
+		"nested": {
+			filter:      squirrel.And{squirrel.Eq{"id": bigOrders}, squirrel.Like{"name": "b%"}},
+			expectedIDs: []int{2},
+		},
+		"expr in": {
+			filter:      squirrel.Expr("id IN (?) OR name = ?", bigOrders, "carol"),
+			expectedIDs: []int{1, 2, 3},
+		},
+		"expr not in": {
+			filter:      squirrel.Expr("id NOT IN (?)", bigOrders),
+			expectedIDs: []int{3},
+		},
+		"inline select": {
+			filter:      squirrel.Expr("id IN (SELECT user_id FROM orders WHERE amount < ? ORDER BY amount DESC LIMIT 1) OR id = ?", 10.0, 3),
+			expectedIDs: []int{1, 3},
+		},
+		"inline grouped select": {
+			filter:      squirrel.Expr("id NOT IN (SELECT user_id FROM orders GROUP BY user_id HAVING SUM(amount) > 20)"),
+			expectedIDs: []int{2, 3},
+		},
+		"qualified": {
+			filter:      squirrel.Expr("id IN (SELECT o.user_id FROM orders AS o WHERE orders.amount > ?)", 10.0),
+			expectedIDs: []int{1, 2},
+		},
+		"grouped subquery": {
+			filter:      squirrel.Eq{"id": squirrel.Select("user_id").From("orders").GroupBy("user_id").Having("COUNT(*) > 1")},
+			expectedIDs: []int{1},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			users, _ := joinTables()
+			filterer := sqlice.NewFilterer(sqlice.WithCatalog(newJoinCatalog(t)))
+			var output []joinUser
+			err := filterer.Filter(users.Rows, &output, test.filter)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			ids := []int{}
+			for _, user := range output {
+				ids = append(ids, user.ID)
+			}
+			if !reflect.DeepEqual(ids, test.expectedIDs) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestCatalog_SelectSubquery(t *testing.T) {
+	users, _ := joinTables()
+	filterer := sqlice.NewFilterer(sqlice.WithCatalog(newJoinCatalog(t)))
+	query := squirrel.Select("name").From("users").
+		Where(squirrel.Eq{"id": squirrel.Select("user_id").From("orders")}).
+		OrderBy("name DESC")
+
+	var output []map[string]interface{}
+	err := filterer.Select(users.Rows, &output, query)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expectedOutput := []map[string]interface{}{{"name": "bob"}, {"name": "alice"}}
+	if !reflect.DeepEqual(output, expectedOutput) {
+		t.Errorf("Expected '%v' got '%v'", expectedOutput, output)
+	}
+}
+
+func TestCatalog_ErrorConditions(t *testing.T) {
+	tests := map[string]struct {
+		filterer *sqlice.Filterer
+		filter   squirrel.Sqlizer
+	}{
+		"no catalog": {
+			filterer: sqlice.NewFilterer(),
+			filter:   squirrel.Eq{"id": squirrel.Select("user_id").From("orders")},
+		},
+		"unknown table": {
+			filter: squirrel.Eq{"id": squirrel.Select("user_id").From("payments")},
+		},
+		"no from": {
+			filter: squirrel.Eq{"id": squirrel.Select("user_id")},
+		},
+		"unsupported from": {
+			filter: squirrel.Eq{"id": squirrel.Select("user_id").From("orders o, users u")},
+		},
+		"multiple columns": {
+			filter: squirrel.Eq{"id": squirrel.Select("user_id", "id").From("orders")},
+		},
+		"star": {
+			filter: squirrel.Eq{"id": squirrel.Select("*").From("orders")},
+		},
+		"unknown column": {
+			filter: squirrel.Eq{"id": squirrel.Select("foo").From("orders")},
+		},
+		"wrong type": {
+			filter: squirrel.Eq{"name": squirrel.Select("user_id").From("orders")},
+		},
+		"ordering": {
+			filter: squirrel.Gt{"id": squirrel.Select("user_id").From("orders")},
+		},
+		"inline unknown qualifier": {
+			filter: squirrel.Expr("id IN (SELECT x.user_id FROM orders o)"),
+		},
+		"inline invalid limit": {
+			filter: squirrel.Expr("id IN (SELECT user_id FROM orders LIMIT ?)", -1),
+		},
+		"inline list": {
+			filter: squirrel.Expr("id IN (SELECT user_id FROM orders, 1)"),
+		},
+		"expr ordering": {
+			filter: squirrel.Expr("id > ?", squirrel.Select("user_id").From("orders")),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			filterer := test.filterer
+			if filterer == nil {
+				filterer = sqlice.NewFilterer(sqlice.WithCatalog(newJoinCatalog(t)))
+			}
+			users, _ := joinTables()
+			var output []joinUser
+			err := filterer.Filter(users.Rows, &output, test.filter)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestCatalog_Register(t *testing.T) {
+	catalog := sqlice.NewCatalog()
+	if err := catalog.Register("", []joinUser{}); err == nil {
+		t.Error("Expected an error for an empty name, got nil")
+	}
+	if err := catalog.Register("users", []int{1}); err == nil {
+		t.Error("Expected an error for a slice of ints, got nil")
+	}
+	if err := catalog.Register("users", joinUser{}); err == nil {
+		t.Error("Expected an error for a struct, got nil")
+	}
+	if err := catalog.Register("users", &joinUser{}); err == nil {
+		t.Error("Expected an error for a pointer to a struct, got nil")
+	}
+	if err := catalog.Register("users", (*[]joinUser)(nil)); err == nil {
+		t.Error("Expected an error for a nil pointer, got nil")
+	}
+}
+
+func TestCatalog_Query(t *testing.T) {
+	tests := map[string]struct {
+		query        squirrel.SelectBuilder
+		expectedRows [][]interface{}
+	}{
+		"star": {
+			query:        squirrel.Select("*").From("users").Where(squirrel.Gt{"id": 1}),
+			expectedRows: [][]interface{}{{int64(2), "bob"}, {int64(3), "carol"}},
+		},
+		"columns": {
+			query:        squirrel.Select("name", "id > 1 AS later").From("users").OrderBy("name DESC").Limit(2),
+			expectedRows: [][]interface{}{{"carol", true}, {"bob", true}},
+		},
+		"aggregates": {
+			query:        squirrel.Select("user_id", "COUNT(*)", "SUM(amount)").From("orders").GroupBy("user_id").Having("COUNT(*) > 1"),
+			expectedRows: [][]interface{}{{int64(1), int64(2), float64(25)}},
+		},
+		"subquery": {
+			query:        squirrel.Select("name").From("users").Where(squirrel.Expr("id NOT IN (?)", squirrel.Select("user_id").From("orders"))),
+			expectedRows: [][]interface{}{{"carol"}},
+		},
+		"dollar placeholders": {
+			query:        squirrel.Select("id").From("orders").Where("amount BETWEEN ? AND ?", 5, 15).PlaceholderFormat(squirrel.Dollar).Offset(1),
+			expectedRows: [][]interface{}{{int64(12)}},
+		},
+		"no rows": {
+			query:        squirrel.Select("id").From("users").Where(squirrel.Eq{"name": "dave"}),
+			expectedRows: [][]interface{}{},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rows, err := test.query.RunWith(newJoinCatalog(t)).Query()
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			defer rows.Close()
+			columns, err := rows.Columns()
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			result := [][]interface{}{}
+			for rows.Next() {
+				row := make([]interface{}, len(columns))
+				dest := make([]interface{}, len(columns))
+				for i := range row {
+					dest[i] = &row[i]
+				}
+				if err := rows.Scan(dest...); err != nil {
+					t.Fatal("Expected no error, got:", err)
+				}
+				result = append(result, row)
+			}
+			if err := rows.Err(); err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if !reflect.DeepEqual(result, test.expectedRows) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedRows, result)
+			}
+		})
+	}
+}
+
+func TestCatalog_QueryRow(t *testing.T) {
+	catalog := newJoinCatalog(t)
+	var name string
+	var total float64
+	err := squirrel.Select("u.name").From("users").Where(squirrel.Eq{"id": 2}).RunWith(catalog).QueryRow().Scan(&name)
+	if err == nil {
+		t.Fatal("Expected an error for a qualified column, got nil")
+	}
+	err = squirrel.Select("name").From("users").Where(squirrel.Eq{"id": 2}).RunWith(catalog).QueryRow().Scan(&name)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if name != "bob" {
+		t.Errorf("Expected '%v' got '%v'", "bob", name)
+	}
+	err = catalog.QueryRow("SELECT SUM(amount) AS total FROM orders WHERE user_id = ?;", 1).Scan(&total)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if total != 25 {
+		t.Errorf("Expected '%v' got '%v'", 25, total)
+	}
+	err = catalog.QueryRow("SELECT name FROM users WHERE id = ?", 4).Scan(&name)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected '%v' got '%v'", sql.ErrNoRows, err)
+	}
+}
+
+func TestCatalog_QueryErrorConditions(t *testing.T) {
+	tests := map[string]struct {
+		query string
+		args  []interface{}
+	}{
+		"not a select":     {query: "UPDATE users SET name = 'x'"},
+		"invalid":          {query: "SELECT FROM users"},
+		"no from":          {query: "SELECT 1"},
+		"unknown table":    {query: "SELECT * FROM payments"},
+		"unknown column":   {query: "SELECT foo FROM users"},
+		"trailing tokens":  {query: "SELECT id FROM users garbage garbage"},
+		"missing arg":      {query: "SELECT id FROM users WHERE id = ?"},
+		"extra arg":        {query: "SELECT id FROM users", args: []interface{}{1}},
+		"named arg":        {query: "SELECT id FROM users WHERE id = ?", args: []interface{}{sql.Named("id", 1)}},
+		"invalid limit":    {query: "SELECT id FROM users LIMIT 'a'"},
+		"join unsupported": {query: "SELECT id FROM users JOIN orders ON users.id = orders.user_id"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rows, err := newJoinCatalog(t).Query(test.query, test.args...)
+			if err == nil {
+				rows.Close()
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestCatalog_Exec(t *testing.T) {
+	_, err := squirrel.Update("users").Set("name", "x").RunWith(newJoinCatalog(t)).Exec()
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+}
+
+func TestCatalog_Close(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		// catalogs only open a database when they're queried, so unused ones don't need closing
+		sqlice.NewCatalog()
+		catalog := sqlice.NewCatalog()
+		if err := catalog.Register("users", []joinUser{{ID: 1}}); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+		var id int
+		if err := catalog.QueryRow("SELECT id FROM users").Scan(&id); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+		if err := catalog.Close(); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+		if _, err := catalog.Query("SELECT id FROM users"); err == nil {
+			t.Fatal("Expected an error after Close, got nil")
+		}
+	}
+	// the goroutines of closed databases exit asynchronously
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Expected '%v' got '%v'", before, after)
+	}
+}
+
+func ExampleCatalog_Query() {
+	type User struct {
+		ID   int
+		Name string
+	}
+	catalog := sqlice.NewCatalog()
+	if err := catalog.Register("users", []User{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}}); err != nil {
+		panic(err)
+	}
+
+	rows, err := squirrel.Select("id", "name").From("users").Where(squirrel.Gt{"id": 1}).RunWith(catalog).Query()
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Name); err != nil {
+			panic(err)
+		}
+		fmt.Println(user)
+	}
+	// Output: {2 bob}
+}
+
+func ExampleWithCatalog() {
+	type User struct {
+		ID   int
+		Name string
+	}
+	type Order struct {
+		UserID int `db:"user_id"`
+	}
+	catalog := sqlice.NewCatalog()
+	if err := catalog.Register("orders", []Order{{UserID: 2}}); err != nil {
+		panic(err)
+	}
+	filterer := sqlice.NewFilterer(sqlice.WithCatalog(catalog))
+
+	users := []User{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}}
+	var customers []User
+	err := filterer.Filter(users, &customers, squirrel.Eq{"id": squirrel.Select("user_id").From("orders")})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(customers)
+	// Output: [{2 bob}]
+}
@@ -0,0 +1,101 @@
+package sqlice
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lann/builder"
+)
+
+// Delete removes the elements of the slice matching the query's WHERE clause in place, keeping the order of the
+// remaining elements, and returns the number of removed elements like sql.Result.RowsAffected. Slice must be a
+// pointer to a slice of structs. Like in MySQL, ORDER BY and LIMIT restrict which of the matching elements are
+// removed, and OFFSET is supported as well. Removed elements are zeroed in the slice's backing array, so a Catalog
+// sharing the slice must have it registered by pointer
+func Delete(slice interface{}, query squirrel.DeleteBuilder) (int64, error) {
+	return defaultFilterer.Delete(slice, query)
+}
+
+// Delete removes the matching elements of the slice in place. See the package level Delete for details
+func (f *Filterer) Delete(slice interface{}, query squirrel.DeleteBuilder) (int64, error) {
+	sliceVal, err := getSliceValue(slice)
+	if err != nil {
+		return 0, fmt.Errorf("failed to validate params: %w", err)
+	}
+	selection, err := planDelete(query)
+	if err != nil {
+		return 0, fmt.Errorf("unable to use query: %w", err)
+	}
+	fields, err := f.getFields(sliceVal.Type().Elem())
+	if err != nil {
+		return 0, fmt.Errorf("unable to use slice type: %w", err)
+	}
+	indexes, err := f.selectIndexes(sliceVal, selection, fields)
+	if err != nil {
+		return 0, err
+	}
+	if len(indexes) == 0 {
+		return 0, nil
+	}
+
+	deleted := make([]bool, sliceVal.Len())
+	for _, index := range indexes {
+		deleted[index] = true
+	}
+	kept := 0
+	for i := 0; i < sliceVal.Len(); i++ {
+		if deleted[i] {
+			continue
+		}
+		if kept != i {
+			sliceVal.Index(kept).Set(sliceVal.Index(i))
+		}
+		kept++
+	}
+	// zero the removed tail so the backing array doesn't keep what it references alive
+	zero := reflect.Zero(sliceVal.Type().Elem())
+	for i := kept; i < sliceVal.Len(); i++ {
+		sliceVal.Index(i).Set(zero)
+	}
+	sliceVal.SetLen(kept)
+	return int64(len(indexes)), nil
+}
+
+// planDelete parses the DELETE statement rendered by the builder
+func planDelete(query squirrel.DeleteBuilder) (*rowSelection, error) {
+	if err := checkStatementClauses(query); err != nil {
+		return nil, err
+	}
+	where, err := statementConditions(query)
+	if err != nil {
+		return nil, err
+	}
+	sql, args, err := builder.Delete(query, "WhereParts").(squirrel.DeleteBuilder).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, args: args}
+	if err := p.expectKeyword("DELETE"); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	table, alias, err := p.parseTableName()
+	if err != nil {
+		return nil, err
+	}
+	selection, err := p.parseRowSelection(table, alias, where)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return selection, nil
+}
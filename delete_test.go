@@ -0,0 +1,163 @@
+package sqlice_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+func TestDelete(t *testing.T) {
+	tests := map[string]struct {
+		query            squirrel.DeleteBuilder
+		expectedAffected int64
+		expectedIDs      []int
+	}{
+		"where": {
+			query:            squirrel.Delete("users").Where(squirrel.Eq{"id": 2}),
+			expectedAffected: 1,
+			expectedIDs:      []int{1, 3, 4, 5},
+		},
+		"multiple": {
+			query:            squirrel.Delete("users").Where("id <> ?", 2),
+			expectedAffected: 4,
+			expectedIDs:      []int{2},
+		},
+		"no where": {
+			query:            squirrel.Delete("users"),
+			expectedAffected: 5,
+			expectedIDs:      []int{},
+		},
+		"no matches": {
+			query:            squirrel.Delete("users").Where(squirrel.Eq{"id": 6}),
+			expectedAffected: 0,
+			expectedIDs:      []int{1, 2, 3, 4, 5},
+		},
+		"null": {
+			query:            squirrel.Delete("users").Where(squirrel.Eq{"discount": nil}),
+			expectedAffected: 2,
+			expectedIDs:      []int{1, 3, 5},
+		},
+		"qualified columns": {
+			query:            squirrel.Delete("users u").Where("u.amount > 12"),
+			expectedAffected: 3,
+			expectedIDs:      []int{1, 4},
+		},
+		"limit": {
+			query:            squirrel.Delete("users").Where("amount > 8").Limit(2),
+			expectedAffected: 2,
+			expectedIDs:      []int{3, 4, 5},
+		},
+		"order by and limit": {
+			query:            squirrel.Delete("users").OrderBy("amount DESC").Limit(2),
+			expectedAffected: 2,
+			expectedIDs:      []int{1, 4, 5},
+		},
+		"offset": {
+			query:            squirrel.Delete("users").OrderBy("id").Limit(1).Offset(1),
+			expectedAffected: 1,
+			expectedIDs:      []int{1, 3, 4, 5},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rows := records()
+			affected, err := sqlice.Delete(&rows, test.query)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if affected != test.expectedAffected {
+				t.Errorf("Expected '%v' got '%v'", test.expectedAffected, affected)
+			}
+			if ids := recordIDs(rows); !reflect.DeepEqual(ids, test.expectedIDs) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestDelete_ErrorConditions(t *testing.T) {
+	rows := records()
+	tests := map[string]struct {
+		slice interface{}
+		query squirrel.DeleteBuilder
+	}{
+		"nil slice":         {slice: nil, query: squirrel.Delete("users")},
+		"non pointer slice": {slice: rows, query: squirrel.Delete("users")},
+		"non slice":         {slice: &rows[0], query: squirrel.Delete("users")},
+		"non struct slice":  {slice: &[]int{1}, query: squirrel.Delete("users")},
+		"no table":          {slice: &rows, query: squirrel.Delete("")},
+		"unknown column":    {slice: &rows, query: squirrel.Delete("users").Where("foo = 1")},
+		"where type":        {slice: &rows, query: squirrel.Delete("users").Where(squirrel.Eq{"name": 1})},
+		"unknown order by":  {slice: &rows, query: squirrel.Delete("users").OrderBy("foo")},
+		"prefix":            {slice: &rows, query: squirrel.Delete("users").Prefix("WITH x AS (SELECT 1)")},
+		"suffix":            {slice: &rows, query: squirrel.Delete("users").Suffix("RETURNING id")},
+		"evaluation error":  {slice: &rows, query: squirrel.Delete("users").Where("id / 0 = 1")},
+		"value filter":      {slice: &rows, query: squirrel.Delete("users").Where(sqlice.ValueFilterFunc(func(interface{}) bool { return true }))},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := sqlice.Delete(test.slice, test.query)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+			if !reflect.DeepEqual(rows, records()) {
+				t.Errorf("Expected '%v' got '%v'", records(), rows)
+			}
+		})
+	}
+}
+
+func TestDelete_Catalog(t *testing.T) {
+	rows := records()
+	catalog := sqlice.NewCatalog()
+	t.Cleanup(func() { catalog.Close() })
+	if err := catalog.Register("users", &rows); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if _, err := sqlice.Delete(&rows, squirrel.Delete("users").Where(squirrel.Eq{"id": 2})); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+
+	var count int
+	if err := catalog.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if count != 4 {
+		t.Errorf("Expected '%v' got '%v'", 4, count)
+	}
+	result, err := catalog.Query("SELECT id FROM users")
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	defer result.Close()
+	ids := []int{}
+	for result.Next() {
+		var id int
+		if err := result.Scan(&id); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+		ids = append(ids, id)
+	}
+	if !reflect.DeepEqual(ids, []int{1, 3, 4, 5}) {
+		t.Errorf("Expected '%v' got '%v'", []int{1, 3, 4, 5}, ids)
+	}
+}
+
+func ExampleDelete() {
+	type FooBar struct {
+		Name string
+		Age  int
+	}
+	people := []FooBar{{Name: "alice", Age: 17}, {Name: "bob", Age: 25}, {Name: "carol", Age: 42}}
+
+	affected, err := sqlice.Delete(&people, squirrel.Delete("people").Where("age < ?", 18))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(affected, people)
+	// Output:
+	// 1 [{bob 25} {carol 42}]
+}
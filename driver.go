@@ -0,0 +1,176 @@
+package sqlice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DriverName is the name the database/sql driver is registered as. The data source name given to sql.Open is
+// the name of a catalog registered with RegisterCatalog
+const DriverName = "sqlice"
+
+func init() {
+	sql.Register(DriverName, Driver{})
+}
+
+// catalogs maps names to the catalogs registered with RegisterCatalog
+var catalogs sync.Map
+
+// RegisterCatalog makes the catalog available to the driver under name, e.g. for
+// sql.Open("sqlice", name). It replaces any catalog registered under the same name, and a nil catalog removes
+// the registration
+func RegisterCatalog(name string, catalog *Catalog) {
+	if catalog == nil {
+		catalogs.Delete(name)
+		return
+	}
+	catalogs.Store(name, catalog)
+}
+
+// Driver is a database/sql driver running queries against the tables of catalogs. Only SELECT queries are
+// supported, with the clauses Select supports. Transactions can be used, but have no effect
+type Driver struct{}
+
+// Open returns a connection to the catalog registered under name
+func (d Driver) Open(name string) (driver.Conn, error) {
+	c, err := d.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+// OpenConnector returns a connector for the catalog registered under name
+func (d Driver) OpenConnector(name string) (driver.Connector, error) {
+	catalog, ok := catalogs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("no catalog registered as '%v'", name)
+	}
+	return connector{catalog: catalog.(*Catalog)}, nil
+}
+
+// connector connects to a catalog through database/sql
+type connector struct {
+	catalog *Catalog
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{catalog: c.catalog}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return Driver{}
+}
+
+// conn is a connection to a catalog. Statements are run when they're executed, against the current rows of the
+// catalog's tables
+type conn struct {
+	catalog *Catalog
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+// Begin returns a transaction with no effect, since the connection only reads
+func (c *conn) Begin() (driver.Tx, error) {
+	return tx{}, nil
+}
+
+func (c *conn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return c.catalog.query(query, values)
+}
+
+func (c *conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return nil, fmt.Errorf("unable to execute '%v': only SELECT queries are supported", query)
+}
+
+// tx is a transaction with no effect
+type tx struct{}
+
+func (tx) Commit() error {
+	return nil
+}
+
+func (tx) Rollback() error {
+	return nil
+}
+
+// stmt is a prepared statement. Statements are only parsed when they're run
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput returns -1, since the number of placeholders is only checked when the statement is parsed
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, positionalValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, positionalValues(args))
+}
+
+// namedValues returns the values of the args. Named args aren't supported
+func namedValues(args []driver.NamedValue) ([]interface{}, error) {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, fmt.Errorf("named arg '%v' is not supported", arg.Name)
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+func positionalValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+// rows holds the result of a query
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	r.values = nil
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
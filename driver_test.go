@@ -0,0 +1,148 @@
+package sqlice_test
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+func openDriverDB(t *testing.T) *sql.DB {
+	catalog := sqlice.NewCatalog()
+	err := catalog.Register("users", records())
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	sqlice.RegisterCatalog(t.Name(), catalog)
+	t.Cleanup(func() { sqlice.RegisterCatalog(t.Name(), nil) })
+
+	db, err := sql.Open(sqlice.DriverName, t.Name())
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestDriver_Query(t *testing.T) {
+	db := openDriverDB(t)
+	rows, err := db.Query("SELECT u.id, u.nickname, created_at FROM users u WHERE u.created_at >= $1 ORDER BY id DESC",
+		time.Date(2021, 1, 1, 1, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expectedColumns := []string{"id", "nickname", "created_at"}
+	if !reflect.DeepEqual(columns, expectedColumns) {
+		t.Errorf("Expected '%v' got '%v'", expectedColumns, columns)
+	}
+
+	var got []string
+	for rows.Next() {
+		var id int
+		var nickname sql.NullString
+		var createdAt time.Time
+		if err := rows.Scan(&id, &nickname, &createdAt); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+		got = append(got, fmt.Sprintf("%v %v %v", id, nickname.String, createdAt.Hour()))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expected := []string{"5 bobby 1", "4  2", "3  1", "1 al 1"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected '%v' got '%v'", expected, got)
+	}
+}
+
+func TestDriver_Statements(t *testing.T) {
+	db := openDriverDB(t)
+	stmt, err := db.Prepare("SELECT name FROM users WHERE id = ?")
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	defer stmt.Close()
+	for id, expected := range map[int]string{1: "alice", 2: "bob"} {
+		var name string
+		if err := stmt.QueryRow(id).Scan(&name); err != nil {
+			t.Fatal("Expected no error, got:", err)
+		}
+		if name != expected {
+			t.Errorf("Expected '%v' got '%v'", expected, name)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if count != 5 {
+		t.Errorf("Expected '%v' got '%v'", 5, count)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+
+	if _, err := db.Exec("DELETE FROM users"); err == nil {
+		t.Error("Expected an error for DELETE, got nil")
+	}
+}
+
+func TestDriver_ErrorConditions(t *testing.T) {
+	db, err := sql.Open(sqlice.DriverName, "unregistered")
+	if err == nil {
+		db.Close()
+		t.Fatal("Expected an error, got nil")
+	}
+}
+
+func TestCatalog_DB(t *testing.T) {
+	catalog := newJoinCatalog(t)
+	var total float64
+	err := squirrel.Select("SUM(amount)").From("orders").Where(squirrel.Eq{"user_id": 1}).RunWith(catalog.DB()).QueryRow().Scan(&total)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	if total != 25 {
+		t.Errorf("Expected '%v' got '%v'", 25, total)
+	}
+}
+
+func ExampleRegisterCatalog() {
+	type User struct {
+		ID   int
+		Name string
+	}
+	catalog := sqlice.NewCatalog()
+	if err := catalog.Register("users", []User{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}}); err != nil {
+		panic(err)
+	}
+	sqlice.RegisterCatalog("fixtures", catalog)
+
+	db, err := sql.Open("sqlice", "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	var name string
+	if err := db.QueryRow("SELECT name FROM users WHERE id = ?", 2).Scan(&name); err != nil {
+		panic(err)
+	}
+	fmt.Println(name)
+	// Output: bob
+}
@@ -0,0 +1,1207 @@
+package sqlice
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// squirrelExprType is the (unexported) type of the Sqlizers returned by squirrel.Expr
+var squirrelExprType = reflect.TypeOf(squirrel.Expr(""))
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenNumber
+	tokenString
+	tokenPlaceholder
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// tokenize splits a SQL string into tokens. Keywords are returned as identifiers, and are recognized by the
+// parser
+func tokenize(sql string) ([]token, error) {
+	var tokens []token
+	runes := []rune(sql)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				i++
+				if i < len(runes) && (runes[i] == '+' || runes[i] == '-') {
+					i++
+				}
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case r == '\'' || r == '"' || r == '`':
+			var text strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated quote starting at position %d", start)
+				}
+				if runes[i] == r {
+					// quotes are escaped by doubling them
+					if i+1 < len(runes) && runes[i+1] == r {
+						text.WriteRune(r)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				text.WriteRune(runes[i])
+				i++
+			}
+			kind := tokenQuotedIdent
+			if r == '\'' {
+				kind = tokenString
+			}
+			tokens = append(tokens, token{kind: kind, text: text.String(), pos: start})
+		case r == '?':
+			i++
+			tokens = append(tokens, token{kind: tokenPlaceholder, text: "?", pos: start})
+		case r == '$' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			i++
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenPlaceholder, text: string(runes[start:i]), pos: start})
+		default:
+			i++
+			if i < len(runes) {
+				switch two := string(runes[start : i+1]); two {
+				case "<>", "!=", "<=", ">=", "||":
+					i++
+					tokens = append(tokens, token{kind: tokenSymbol, text: two, pos: start})
+					continue
+				}
+			}
+			if !strings.ContainsRune("=<>(),.+-*/;", r) {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", r, start)
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(r), pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// exprNode is a node of a parsed SQL expression
+type exprNode interface{}
+
+type (
+	// columnNode references the column with the given name
+	columnNode struct {
+		name string
+	}
+	// valueNode is a literal or bound placeholder value. nil is NULL
+	valueNode struct {
+		value interface{}
+	}
+	// notNode is NOT operand
+	notNode struct {
+		operand exprNode
+	}
+	// negateNode is -operand
+	negateNode struct {
+		operand exprNode
+	}
+	// arithmeticNode is left op right for the arithmetic operators +, -, * and /, and || for concatenation
+	arithmeticNode struct {
+		op          string
+		left, right exprNode
+	}
+	// logicalNode is left AND right, or left OR right
+	logicalNode struct {
+		and         bool
+		left, right exprNode
+	}
+	// compareNode is left op right for the comparison operators. negate is set for <> and !=
+	compareNode struct {
+		op          numericOperation
+		negate      bool
+		left, right exprNode
+	}
+	// inNode is operand [NOT] IN (list...)
+	inNode struct {
+		operand exprNode
+		list    []exprNode
+		negate  bool
+	}
+	// isNullNode is operand IS [NOT] NULL
+	isNullNode struct {
+		operand exprNode
+		negate  bool
+	}
+	// betweenNode is operand [NOT] BETWEEN low AND high
+	betweenNode struct {
+		operand, low, high exprNode
+		negate             bool
+	}
+	// rowNode is a row value, (elems...)
+	rowNode struct {
+		elems []exprNode
+	}
+	// funcNode is a function call, name(args...). name is upper case. star is set for name(*), and distinct
+	// for name(DISTINCT args...)
+	funcNode struct {
+		name     string
+		args     []exprNode
+		star     bool
+		distinct bool
+	}
+	// likeNode is operand [NOT] LIKE pattern, or ILIKE if caseInsensitive is set
+	likeNode struct {
+		operand, pattern exprNode
+		negate           bool
+		caseInsensitive  bool
+	}
+	// subqueryNode is a SELECT from table, either written in the list of IN or a squirrel.SelectBuilder bound
+	// to a placeholder. Bound subqueries may also be compared with = and <>, which squirrel renders for Eq and
+	// NotEq
+	subqueryNode struct {
+		table string
+		plan  *selectPlan
+	}
+	// filterNode is a filter given to squirrel's Where or Having, like squirrel.Eq, parsed from its SQL. The
+	// types of its values are checked against the fields like Filter checks them
+	filterNode struct {
+		checks []valueCheck
+		expr   exprNode
+	}
+)
+
+// parser is a recursive descent parser for SQL expressions. Placeholders are bound to args as they are parsed
+type parser struct {
+	tokens  []token
+	pos     int
+	args    []interface{}
+	nextArg int
+}
+
+// parseExpression parses a SQL boolean expression, such as the ones given to squirrel.Expr or the WHERE
+// clause of a query, binding any placeholders to args
+func parseExpression(sql string, args []interface{}) (exprNode, error) {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, args: args}
+	node, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// backup undoes the call to next that returned t
+func (p *parser) backup(t token) {
+	if t.kind != tokenEOF {
+		p.pos--
+	}
+}
+
+// isKeyword reports whether t is the given keyword, which must be upper case
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenIdent && strings.ToUpper(t.text) == keyword
+}
+
+func (t token) isSymbol(symbol string) bool {
+	return t.kind == tokenSymbol && t.text == symbol
+}
+
+// acceptKeyword consumes the next token if it's one of the given keywords, returning which one it was
+func (p *parser) acceptKeyword(keywords ...string) (string, bool) {
+	for _, keyword := range keywords {
+		if p.peek().isKeyword(keyword) {
+			p.next()
+			return keyword, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) acceptSymbol(symbol string) bool {
+	if p.peek().isSymbol(symbol) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if _, ok := p.acceptKeyword(keyword); !ok {
+		return p.unexpected(keyword)
+	}
+	return nil
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	if !p.acceptSymbol(symbol) {
+		return p.unexpected("'" + symbol + "'")
+	}
+	return nil
+}
+
+func (p *parser) expectEnd() error {
+	if p.peek().kind != tokenEOF {
+		return p.unexpected("end of expression")
+	}
+	if p.nextArg != len(p.args) {
+		return fmt.Errorf("expression has %d placeholders, but %d args were given", p.nextArg, len(p.args))
+	}
+	return nil
+}
+
+func (p *parser) unexpected(expected string) error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return fmt.Errorf("expected %v at end of expression", expected)
+	}
+	return fmt.Errorf("expected %v at position %d, got '%v'", expected, t.pos, t.text)
+}
+
+func (p *parser) parseExpr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptKeyword("OR"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: false, left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptKeyword("AND"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: true, left: left, right: right}
+	}
+}
+
+func (p *parser) parseNot() (exprNode, error) {
+	if _, ok := p.acceptKeyword("NOT"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parsePredicate()
+}
+
+var comparisonOperators = map[string]compareNode{
+	"=":  {op: opEQ},
+	"<>": {op: opEQ, negate: true},
+	"!=": {op: opEQ, negate: true},
+	"<":  {op: opLT},
+	">":  {op: opGT},
+	"<=": {op: opLTOrEQ},
+	">=": {op: opGTOrEQ},
+}
+
+// parsePredicate parses a value optionally followed by a comparison, IS NULL, IN, BETWEEN or LIKE
+func (p *parser) parsePredicate() (exprNode, error) {
+	left, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind == tokenSymbol {
+		cmp, ok := comparisonOperators[t.text]
+		if !ok {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		cmp.left, cmp.right = left, right
+		return cmp, nil
+	}
+
+	if _, ok := p.acceptKeyword("IS"); ok {
+		_, negate := p.acceptKeyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return isNullNode{operand: left, negate: negate}, nil
+	}
+
+	_, negate := p.acceptKeyword("NOT")
+	keyword, ok := p.acceptKeyword("IN", "BETWEEN", "LIKE", "ILIKE")
+	if !ok {
+		if negate {
+			return nil, p.unexpected("IN, BETWEEN, LIKE or ILIKE")
+		}
+		return left, nil
+	}
+	switch keyword {
+	case "IN":
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return inNode{operand: left, list: list, negate: negate}, nil
+	case "BETWEEN":
+		low, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		return betweenNode{operand: left, low: low, high: high, negate: negate}, nil
+	default:
+		pattern, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		return likeNode{operand: left, pattern: pattern, negate: negate, caseInsensitive: keyword == "ILIKE"}, nil
+	}
+}
+
+// parseList parses the parenthesized list of an IN. Placeholders bound to slices are expanded, as squirrel
+// does for Eq
+func (p *parser) parseList() ([]exprNode, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	if _, ok := p.acceptKeyword("SELECT"); ok {
+		table, plan, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return []exprNode{subqueryNode{table: table, plan: plan}}, nil
+	}
+	var list []exprNode
+	for {
+		elem, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		if value, ok := elem.(valueNode); ok && value.value != nil && isListType(value.value) {
+			listVal := reflect.ValueOf(value.value)
+			for i := 0; i < listVal.Len(); i++ {
+				elemValue, err := sanitizeValue(listVal.Index(i).Interface())
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, valueNode{value: elemValue})
+			}
+		} else {
+			list = append(list, elem)
+		}
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// parseConcat parses values joined by ||
+func (p *parser) parseConcat() (exprNode, error) {
+	return p.parseBinary(p.parseSum, "||")
+}
+
+// parseSum parses values joined by + and -
+func (p *parser) parseSum() (exprNode, error) {
+	return p.parseBinary(p.parseProduct, "+", "-")
+}
+
+// parseProduct parses operands joined by * and /
+func (p *parser) parseProduct() (exprNode, error) {
+	return p.parseBinary(p.parseOperand, "*", "/")
+}
+
+// parseBinary parses the left associative arithmetic operators ops, with operands parsed by parseOperand
+func (p *parser) parseBinary(parseOperand func() (exprNode, error), ops ...string) (exprNode, error) {
+	left, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := "", false
+		for _, candidate := range ops {
+			if p.acceptSymbol(candidate) {
+				op, ok = candidate, true
+				break
+			}
+		}
+		if !ok {
+			return left, nil
+		}
+		right, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmeticNode{op: op, left: left, right: right}
+	}
+}
+
+// parseOperand parses a value: a literal, placeholder, column, or parenthesized expression
+func (p *parser) parseOperand() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return valueNode{value: i}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%v' at position %d", t.text, t.pos)
+		}
+		return valueNode{value: f}, nil
+	case tokenString:
+		return valueNode{value: t.text}, nil
+	case tokenPlaceholder:
+		return p.bindPlaceholder(t)
+	case tokenIdent, tokenQuotedIdent:
+		if t.kind == tokenIdent {
+			switch strings.ToUpper(t.text) {
+			case "NULL":
+				return valueNode{value: nil}, nil
+			case "TRUE":
+				return valueNode{value: true}, nil
+			case "FALSE":
+				return valueNode{value: false}, nil
+			}
+			if p.peek().isSymbol("(") {
+				return p.parseCall(t)
+			}
+		}
+		name := t.text
+		for p.acceptSymbol(".") {
+			part := p.next()
+			if part.kind != tokenIdent && part.kind != tokenQuotedIdent {
+				p.backup(part)
+				return nil, p.unexpected("column name")
+			}
+			name += "." + part.text
+		}
+		return columnNode{name: name}, nil
+	case tokenSymbol:
+		switch t.text {
+		case "(":
+			node, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if p.peek().isSymbol(",") {
+				row := rowNode{elems: []exprNode{node}}
+				for p.acceptSymbol(",") {
+					elem, err := p.parseExpr()
+					if err != nil {
+						return nil, err
+					}
+					row.elems = append(row.elems, elem)
+				}
+				node = row
+			}
+			if err := p.expectSymbol(")"); err != nil {
+				return nil, err
+			}
+			return node, nil
+		case "-":
+			operand, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return negateNode{operand: operand}, nil
+		}
+	}
+	p.backup(t)
+	return nil, p.unexpected("value")
+}
+
+// parseCall parses the arguments of a call to the function named by t
+func (p *parser) parseCall(t token) (exprNode, error) {
+	call := funcNode{name: strings.ToUpper(t.text)}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	if p.acceptSymbol("*") {
+		call.star = true
+	} else if !p.peek().isSymbol(")") {
+		_, call.distinct = p.acceptKeyword("DISTINCT")
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// bindPlaceholder returns the arg for a placeholder. '?' placeholders are bound in order, while '$n'
+// placeholders are bound to the nth arg
+func (p *parser) bindPlaceholder(t token) (exprNode, error) {
+	index := p.nextArg
+	if t.text != "?" {
+		n, err := strconv.Atoi(t.text[1:])
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid placeholder '%v' at position %d", t.text, t.pos)
+		}
+		index = n - 1
+	}
+	if index >= len(p.args) {
+		return nil, fmt.Errorf("no arg given for placeholder at position %d", t.pos)
+	}
+	if index >= p.nextArg {
+		p.nextArg = index + 1
+	}
+	value, err := sanitizeValue(p.args[index])
+	if err != nil {
+		return nil, fmt.Errorf("unable to get value of arg %d: %w", index+1, err)
+	}
+	if query, ok := value.(squirrel.SelectBuilder); ok {
+		return newSubquery(query)
+	}
+	return valueNode{value: value}, nil
+}
+
+// rewriteNode returns a copy of node with nodes replaced by fn. fn is called for node first, and returns the
+// replacement and true to replace it, or false to rewrite its children instead
+func rewriteNode(node exprNode, fn func(exprNode) (exprNode, bool, error)) (exprNode, error) {
+	replacement, ok, err := fn(node)
+	if err != nil || ok {
+		return replacement, err
+	}
+	rewrite := func(child exprNode) exprNode {
+		if err != nil {
+			return child
+		}
+		child, err = rewriteNode(child, fn)
+		return child
+	}
+	rewriteAll := func(children []exprNode) []exprNode {
+		rewritten := make([]exprNode, len(children))
+		for i, child := range children {
+			rewritten[i] = rewrite(child)
+		}
+		return rewritten
+	}
+	switch n := node.(type) {
+	case notNode:
+		n.operand = rewrite(n.operand)
+		node = n
+	case negateNode:
+		n.operand = rewrite(n.operand)
+		node = n
+	case logicalNode:
+		n.left, n.right = rewrite(n.left), rewrite(n.right)
+		node = n
+	case arithmeticNode:
+		n.left, n.right = rewrite(n.left), rewrite(n.right)
+		node = n
+	case compareNode:
+		n.left, n.right = rewrite(n.left), rewrite(n.right)
+		node = n
+	case inNode:
+		n.operand, n.list = rewrite(n.operand), rewriteAll(n.list)
+		node = n
+	case isNullNode:
+		n.operand = rewrite(n.operand)
+		node = n
+	case betweenNode:
+		n.operand, n.low, n.high = rewrite(n.operand), rewrite(n.low), rewrite(n.high)
+		node = n
+	case likeNode:
+		n.operand, n.pattern = rewrite(n.operand), rewrite(n.pattern)
+		node = n
+	case rowNode:
+		n.elems = rewriteAll(n.elems)
+		node = n
+	case funcNode:
+		n.args = rewriteAll(n.args)
+		node = n
+	case filterNode:
+		n.expr = rewrite(n.expr)
+		node = n
+	}
+	return node, err
+}
+
+// evaluator evaluates a compiled expression for an item. A nil result is SQL NULL
+type evaluator func(item reflect.Value) (interface{}, error)
+
+// compileExpression parses the SQL expression and compiles it into a matcher. Items match if the expression
+// evaluates to true
+func (f *Filterer) compileExpression(sql string, args []interface{}, fields map[string]fieldInfo) (matcher, error) {
+	node, err := parseExpression(sql, args)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse expression '%v': %w", sql, err)
+	}
+	eval, err := f.compileNode(node, fields)
+	if err != nil {
+		return nil, err
+	}
+	return evaluatorMatcher(eval), nil
+}
+
+// evaluatorMatcher returns a matcher for the items a boolean expression evaluates to true for
+func evaluatorMatcher(eval evaluator) matcher {
+	return func(item reflect.Value) (bool, error) {
+		result, err := eval(item)
+		return result == true, err
+	}
+}
+
+// compileNode compiles a node of a parsed expression into an evaluator, resolving its columns
+func (f *Filterer) compileNode(node exprNode, fields map[string]fieldInfo) (evaluator, error) {
+	switch node := node.(type) {
+	case valueNode:
+		return func(reflect.Value) (interface{}, error) {
+			return node.value, nil
+		}, nil
+	case columnNode:
+		field, ok := fields[f.normalizeName(node.name)]
+		if !ok {
+			return nil, fmt.Errorf("struct has no field named '%v'", node.name)
+		}
+		return func(item reflect.Value) (interface{}, error) {
+			value, ok, err := fieldValue(item, field)
+			if err != nil || !ok {
+				return nil, err
+			}
+			return value.Interface(), nil
+		}, nil
+	case notNode:
+		operand, err := f.compileNode(node.operand, fields)
+		if err != nil {
+			return nil, err
+		}
+		return func(item reflect.Value) (interface{}, error) {
+			value, err := operand(item)
+			if err != nil || value == nil {
+				return nil, err
+			}
+			b, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("NOT expects a boolean, got %T", value)
+			}
+			return !b, nil
+		}, nil
+	case negateNode:
+		operand, err := f.compileNode(node.operand, fields)
+		if err != nil {
+			return nil, err
+		}
+		return func(item reflect.Value) (interface{}, error) {
+			value, err := operand(item)
+			if err != nil || value == nil {
+				return nil, err
+			}
+			return negateNumber(value)
+		}, nil
+	case logicalNode:
+		return f.compileLogical(node, fields)
+	case arithmeticNode:
+		left, right, err := f.compilePair(node.left, node.right, fields)
+		if err != nil {
+			return nil, err
+		}
+		return func(item reflect.Value) (interface{}, error) {
+			l, r, err := evaluatePair(item, left, right)
+			if err != nil || l == nil || r == nil {
+				return nil, err
+			}
+			return calculate(node.op, l, r)
+		}, nil
+	case compareNode:
+		if _, ok := node.left.(rowNode); ok {
+			return f.compileRowCompare(node, fields)
+		}
+		if _, ok := node.right.(subqueryNode); ok && node.op == opEQ {
+			return f.compileIn(inNode{operand: node.left, list: []exprNode{node.right}, negate: node.negate}, fields)
+		}
+		left, right, err := f.compilePair(node.left, node.right, fields)
+		if err != nil {
+			return nil, err
+		}
+		return func(item reflect.Value) (interface{}, error) {
+			l, r, err := evaluatePair(item, left, right)
+			if err != nil || l == nil || r == nil {
+				return nil, err
+			}
+			result, ok := sqlCompare(l, r, node.op)
+			if !ok {
+				return nil, nil
+			}
+			return result != node.negate, nil
+		}, nil
+	case isNullNode:
+		operand, err := f.compileNode(node.operand, fields)
+		if err != nil {
+			return nil, err
+		}
+		return func(item reflect.Value) (interface{}, error) {
+			value, err := operand(item)
+			if err != nil {
+				return nil, err
+			}
+			return (value == nil) != node.negate, nil
+		}, nil
+	case inNode:
+		return f.compileIn(node, fields)
+	case betweenNode:
+		// operand BETWEEN low AND high is operand >= low AND operand <= high
+		var between exprNode = logicalNode{
+			and:   true,
+			left:  compareNode{op: opGTOrEQ, left: node.operand, right: node.low},
+			right: compareNode{op: opLTOrEQ, left: node.operand, right: node.high},
+		}
+		if node.negate {
+			between = notNode{operand: between}
+		}
+		return f.compileNode(between, fields)
+	case likeNode:
+		return f.compileLikeNode(node, fields)
+	case rowNode:
+		return nil, errors.New("row values can only be compared to other row values")
+	case subqueryNode:
+		return nil, errors.New("subqueries can only be used with IN, = and <>")
+	case filterNode:
+		if err := f.checkValues(node.checks, fields); err != nil {
+			return nil, err
+		}
+		return f.compileNode(node.expr, fields)
+	case funcNode:
+		if aggregateFuncs[node.name] {
+			return nil, fmt.Errorf("aggregate function %v is not allowed here", node.name)
+		}
+		return nil, fmt.Errorf("unknown function %v", node.name)
+	default:
+		return nil, fmt.Errorf("unsupported expression %T", node)
+	}
+}
+
+// compileRowCompare compiles a comparison of two row values. Rows are equal if all their elements are, and are
+// otherwise ordered by their first pair of unequal elements. NULL elements make the result NULL unless an
+// earlier pair already decided it
+func (f *Filterer) compileRowCompare(node compareNode, fields map[string]fieldInfo) (evaluator, error) {
+	leftRow, leftOk := node.left.(rowNode)
+	rightRow, rightOk := node.right.(rowNode)
+	if !leftOk || !rightOk {
+		return nil, errors.New("row values can only be compared to other row values")
+	}
+	if len(leftRow.elems) != len(rightRow.elems) {
+		return nil, fmt.Errorf("cannot compare rows of %d and %d values", len(leftRow.elems), len(rightRow.elems))
+	}
+	left := make([]evaluator, len(leftRow.elems))
+	right := make([]evaluator, len(rightRow.elems))
+	for i := range left {
+		var err error
+		if left[i], right[i], err = f.compilePair(leftRow.elems[i], rightRow.elems[i], fields); err != nil {
+			return nil, err
+		}
+	}
+	return func(item reflect.Value) (interface{}, error) {
+		for i := range left {
+			l, r, err := evaluatePair(item, left[i], right[i])
+			if err != nil || l == nil || r == nil {
+				return nil, err
+			}
+			equal, ok := sqlCompare(l, r, opEQ)
+			if !ok {
+				return nil, nil
+			}
+			if equal {
+				continue
+			}
+			if node.op == opEQ {
+				return node.negate, nil
+			}
+			result, _ := sqlCompare(l, r, node.op)
+			return result, nil
+		}
+		// all elements are equal
+		switch node.op {
+		case opEQ:
+			return !node.negate, nil
+		case opLTOrEQ, opGTOrEQ:
+			return true, nil
+		default:
+			return false, nil
+		}
+	}, nil
+}
+
+func (f *Filterer) compilePair(leftNode, rightNode exprNode, fields map[string]fieldInfo) (left, right evaluator, err error) {
+	left, err = f.compileNode(leftNode, fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	right, err = f.compileNode(rightNode, fields)
+	return left, right, err
+}
+
+func evaluatePair(item reflect.Value, left, right evaluator) (l, r interface{}, err error) {
+	l, err = left(item)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err = right(item)
+	return l, r, err
+}
+
+// compileLogical compiles AND and OR using SQL's three valued logic: NULL AND false is false, NULL OR true is
+// true, and otherwise a NULL operand makes the result NULL
+func (f *Filterer) compileLogical(node logicalNode, fields map[string]fieldInfo) (evaluator, error) {
+	left, right, err := f.compilePair(node.left, node.right, fields)
+	if err != nil {
+		return nil, err
+	}
+	// the value that decides the result regardless of the other operand
+	decisive := !node.and
+	return func(item reflect.Value) (interface{}, error) {
+		null := false
+		for _, operand := range []evaluator{left, right} {
+			value, err := operand(item)
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				null = true
+				continue
+			}
+			b, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("AND and OR expect booleans, got %T", value)
+			}
+			if b == decisive {
+				return decisive, nil
+			}
+		}
+		if null {
+			return nil, nil
+		}
+		return !decisive, nil
+	}, nil
+}
+
+func (f *Filterer) compileIn(node inNode, fields map[string]fieldInfo) (evaluator, error) {
+	operand, err := f.compileNode(node.operand, fields)
+	if err != nil {
+		return nil, err
+	}
+	var list []evaluator
+	for _, elem := range node.list {
+		if subquery, ok := elem.(subqueryNode); ok {
+			// subqueries are run once, when compiling
+			values, err := f.subqueryValues(subquery)
+			if err != nil {
+				return nil, err
+			}
+			for _, value := range values {
+				value := value
+				list = append(list, func(reflect.Value) (interface{}, error) {
+					return value, nil
+				})
+			}
+			continue
+		}
+		eval, err := f.compileNode(elem, fields)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, eval)
+	}
+	return func(item reflect.Value) (interface{}, error) {
+		value, err := operand(item)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		null := false
+		for _, elem := range list {
+			elemValue, err := elem(item)
+			if err != nil {
+				return nil, err
+			}
+			if elemValue == nil {
+				null = true
+				continue
+			}
+			if equal, ok := sqlCompare(value, elemValue, opEQ); ok && equal {
+				return !node.negate, nil
+			}
+		}
+		if null {
+			return nil, nil
+		}
+		return node.negate, nil
+	}, nil
+}
+
+func (f *Filterer) compileLikeNode(node likeNode, fields map[string]fieldInfo) (evaluator, error) {
+	operand, err := f.compileNode(node.operand, fields)
+	if err != nil {
+		return nil, err
+	}
+	compilePattern := func(pattern interface{}) (*regexp.Regexp, error) {
+		s, ok := pattern.(string)
+		if !ok {
+			return nil, errors.New("expression must be a string")
+		}
+		reString := expressionToRegexp(s)
+		if node.caseInsensitive {
+			reString = `(?i)` + reString
+		}
+		return regexp.Compile(reString)
+	}
+
+	// patterns are usually constant, so they only need to be compiled once
+	var pattern func(item reflect.Value) (*regexp.Regexp, error)
+	if value, ok := node.pattern.(valueNode); ok && value.value != nil {
+		re, err := compilePattern(value.value)
+		if err != nil {
+			return nil, err
+		}
+		pattern = func(reflect.Value) (*regexp.Regexp, error) {
+			return re, nil
+		}
+	} else {
+		patternEval, err := f.compileNode(node.pattern, fields)
+		if err != nil {
+			return nil, err
+		}
+		pattern = func(item reflect.Value) (*regexp.Regexp, error) {
+			value, err := patternEval(item)
+			if err != nil || value == nil {
+				return nil, err
+			}
+			return compilePattern(value)
+		}
+	}
+
+	return func(item reflect.Value) (interface{}, error) {
+		value, err := operand(item)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		re, err := pattern(item)
+		if err != nil || re == nil {
+			return nil, err
+		}
+		return re.MatchString(fmt.Sprint(value)) != node.negate, nil
+	}, nil
+}
+
+// sqlCompare evaluates 'a op b' for two non-NULL values. Numbers of different kinds are converted so they can
+// be compared. ok is false if the values can't be compared
+func sqlCompare(a, b interface{}, op numericOperation) (result, ok bool) {
+	v1, v2 := coerceNumbers(reflect.ValueOf(a), reflect.ValueOf(b))
+	if reducedKind(v1.Kind()) != reducedKind(v2.Kind()) {
+		return false, false
+	}
+	switch reducedKind(v1.Kind()) {
+	case reflect.Int64, reflect.Uint64, reflect.Float64, reflect.String:
+		return compareValues(v1, v2, op), true
+	case reflect.Struct:
+		if v1.Type() == timeType && v2.Type() == timeType {
+			return compareValues(v1, v2, op), true
+		}
+	case reflect.Bool:
+		// false sorts before true
+		return compareValues(reflect.ValueOf(boolToInt(v1.Bool())), reflect.ValueOf(boolToInt(v2.Bool())), op), true
+	}
+	if op == opEQ {
+		return valuesEqual(v1, v2), true
+	}
+	return false, false
+}
+
+// coerceNumbers converts v1 and v2 to float64 if they're numbers of different kinds
+func coerceNumbers(v1, v2 reflect.Value) (reflect.Value, reflect.Value) {
+	k1, k2 := reducedKind(v1.Kind()), reducedKind(v2.Kind())
+	if k1 == k2 || !isNumberKind(k1) || !isNumberKind(k2) {
+		return v1, v2
+	}
+	return reflect.ValueOf(toFloat(v1)), reflect.ValueOf(toFloat(v2))
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch reducedKind(kind) {
+	case reflect.Int64, reflect.Uint64, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// toFloat converts a number to a float64
+func toFloat(v reflect.Value) float64 {
+	switch reducedKind(v.Kind()) {
+	case reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint64:
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// calculate returns a op b for the operators of an arithmeticNode. Integers are calculated as int64 or uint64,
+// with integer division truncating like in SQL, and other numbers as float64
+func calculate(op string, a, b interface{}) (interface{}, error) {
+	v1, v2 := coerceNumbers(reflect.ValueOf(a), reflect.ValueOf(b))
+	if op == "||" {
+		if v1.Kind() != reflect.String || v2.Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot concatenate %T and %T", a, b)
+		}
+		return v1.String() + v2.String(), nil
+	}
+	if !isNumberKind(v1.Kind()) || reducedKind(v1.Kind()) != reducedKind(v2.Kind()) {
+		return nil, fmt.Errorf("cannot calculate %T %v %T", a, op, b)
+	}
+	if op == "/" && v2.IsZero() {
+		return nil, errors.New("division by zero")
+	}
+	switch reducedKind(v1.Kind()) {
+	case reflect.Int64:
+		x, y := v1.Int(), v2.Int()
+		switch op {
+		case "+":
+			return x + y, nil
+		case "-":
+			return x - y, nil
+		case "*":
+			return x * y, nil
+		default:
+			return x / y, nil
+		}
+	case reflect.Uint64:
+		x, y := v1.Uint(), v2.Uint()
+		switch op {
+		case "+":
+			return x + y, nil
+		case "-":
+			if x < y {
+				return negateNumber(y - x)
+			}
+			return x - y, nil
+		case "*":
+			return x * y, nil
+		default:
+			return x / y, nil
+		}
+	default:
+		x, y := v1.Float(), v2.Float()
+		switch op {
+		case "+":
+			return x + y, nil
+		case "-":
+			return x - y, nil
+		case "*":
+			return x * y, nil
+		default:
+			return x / y, nil
+		}
+	}
+}
+
+func negateNumber(value interface{}) (interface{}, error) {
+	v := reflect.ValueOf(value)
+	switch reducedKind(v.Kind()) {
+	case reflect.Int64:
+		return -v.Int(), nil
+	case reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return -float64(v.Uint()), nil
+		}
+		return -int64(v.Uint()), nil
+	case reflect.Float64:
+		return -v.Float(), nil
+	default:
+		return nil, fmt.Errorf("cannot negate %T", value)
+	}
+}
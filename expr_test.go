@@ -0,0 +1,240 @@
+package sqlice_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+func TestFilter_Expr(t *testing.T) {
+	one := 1
+	input := []Record{
+		{ID: 1, Name: "alice", Status: "active", Amount: 1.5, Active: true},
+		{ID: 2, Name: "bob", Status: "banned", Amount: 2.5, Discount: &one},
+		{ID: 3, Name: "Carol", Status: "active", Amount: 3.5, Discount: &one, Active: true},
+	}
+
+	tests := map[string]struct {
+		filter      squirrel.Sqlizer
+		expectedIDs []int
+	}{
+		"placeholder": {
+			filter:      squirrel.Expr("id = ?", 2),
+			expectedIDs: []int{2},
+		},
+		"dollar placeholders": {
+			filter:      squirrel.Expr("id > $2 AND name <> $1", "carol", 1),
+			expectedIDs: []int{2, 3},
+		},
+		"literals": {
+			filter:      squirrel.Expr("status = 'active' AND amount >= 2"),
+			expectedIDs: []int{3},
+		},
+		"escaped quote": {
+			filter:      squirrel.Expr("name <> 'o''brien'"),
+			expectedIDs: []int{1, 2, 3},
+		},
+		"quoted column": {
+			filter:      squirrel.Expr(`"name" = ?`, "bob"),
+			expectedIDs: []int{2},
+		},
+		"or": {
+			filter:      squirrel.Expr("id = 1 OR id = 3"),
+			expectedIDs: []int{1, 3},
+		},
+		"precedence": {
+			filter:      squirrel.Expr("id = 1 OR id = 2 AND status = 'active'"),
+			expectedIDs: []int{1},
+		},
+		"parentheses": {
+			filter:      squirrel.Expr("(id = 1 OR id = 2) AND status = 'active'"),
+			expectedIDs: []int{1},
+		},
+		"not": {
+			filter:      squirrel.Expr("NOT (status = ?)", "active"),
+			expectedIDs: []int{2},
+		},
+		"boolean column": {
+			filter:      squirrel.Expr("active AND NOT active = false"),
+			expectedIDs: []int{1, 3},
+		},
+		"in": {
+			filter:      squirrel.Expr("id IN (1, ?)", 3),
+			expectedIDs: []int{1, 3},
+		},
+		"in slice arg": {
+			filter:      squirrel.Expr("name IN (?)", []string{"alice", "bob"}),
+			expectedIDs: []int{1, 2},
+		},
+		"not in": {
+			filter:      squirrel.Expr("id NOT IN (1, 2)"),
+			expectedIDs: []int{3},
+		},
+		"is null": {
+			filter:      squirrel.Expr("discount IS NULL"),
+			expectedIDs: []int{1},
+		},
+		"is not null": {
+			filter:      squirrel.Expr("discount IS NOT NULL"),
+			expectedIDs: []int{2, 3},
+		},
+		"null comparison": {
+			filter:      squirrel.Expr("discount = ? OR discount <> ?", nil, nil),
+			expectedIDs: []int{},
+		},
+		"null in or": {
+			filter:      squirrel.Expr("discount = 1 OR id = 1"),
+			expectedIDs: []int{1, 2, 3},
+		},
+		"not null comparison": {
+			filter:      squirrel.Expr("NOT discount = 1"),
+			expectedIDs: []int{},
+		},
+		"between": {
+			filter:      squirrel.Expr("amount BETWEEN ? AND ?", 2, 3.5),
+			expectedIDs: []int{2, 3},
+		},
+		"not between": {
+			filter:      squirrel.Expr("id NOT BETWEEN 2 AND 3"),
+			expectedIDs: []int{1},
+		},
+		"like": {
+			filter:      squirrel.Expr("name LIKE ?", "%o%"),
+			expectedIDs: []int{2, 3},
+		},
+		"not like": {
+			filter:      squirrel.Expr("name NOT LIKE 'a%'"),
+			expectedIDs: []int{2, 3},
+		},
+		"ilike": {
+			filter:      squirrel.Expr("name ILIKE 'c%'"),
+			expectedIDs: []int{3},
+		},
+		"mixed numbers": {
+			filter:      squirrel.Expr("amount > id AND amount < 3"),
+			expectedIDs: []int{1, 2},
+		},
+		"negative number": {
+			filter:      squirrel.Expr("-id < -2"),
+			expectedIDs: []int{3},
+		},
+		"arithmetic": {
+			filter:      squirrel.Expr("id * 2 - 1 = ? OR id + 1 * 2 = 3", 3),
+			expectedIDs: []int{1, 2},
+		},
+		"integer division": {
+			filter:      squirrel.Expr("id / 2 = 1 AND amount / 2 > 1.5"),
+			expectedIDs: []int{3},
+		},
+		"concatenation": {
+			filter:      squirrel.Expr("name || '!' = 'bob!'"),
+			expectedIDs: []int{2},
+		},
+		"null arithmetic": {
+			filter:      squirrel.Expr("discount + 1 = 2"),
+			expectedIDs: []int{2, 3},
+		},
+		"column comparison": {
+			filter:      squirrel.Expr("discount = id"),
+			expectedIDs: []int{},
+		},
+		"keywords case": {
+			filter:      squirrel.Expr("id in (1) or discount is not null"),
+			expectedIDs: []int{1, 2, 3},
+		},
+		"combined": {
+			filter:      squirrel.And{squirrel.Expr("amount > ?", 2), squirrel.Eq{"status": "active"}},
+			expectedIDs: []int{3},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []Record
+			err := sqlice.Filter(input, &output, test.filter)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if ids := recordIDs(output); !reflect.DeepEqual(ids, test.expectedIDs) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestFilter_ExprErrorConditions(t *testing.T) {
+	tests := map[string]struct {
+		filter squirrel.Sqlizer
+	}{
+		"unknown column":        {filter: squirrel.Expr("foo = 1")},
+		"too few args":          {filter: squirrel.Expr("id = ? AND name = ?", 1)},
+		"too many args":         {filter: squirrel.Expr("id = ?", 1, 2)},
+		"unterminated string":   {filter: squirrel.Expr("name = 'bob")},
+		"trailing tokens":       {filter: squirrel.Expr("id = 1 2")},
+		"missing operand":       {filter: squirrel.Expr("id =")},
+		"unbalanced parens":     {filter: squirrel.Expr("(id = 1")},
+		"unexpected character":  {filter: squirrel.Expr("id = 1#")},
+		"bad between":           {filter: squirrel.Expr("id BETWEEN 1 OR 2")},
+		"bad not":               {filter: squirrel.Expr("id NOT = 1")},
+		"non string like":       {filter: squirrel.Expr("name LIKE 1")},
+		"invalid dollar":        {filter: squirrel.Expr("id = $0", 1)},
+		"dollar out of range":   {filter: squirrel.Expr("id = $2", 1)},
+		"unsupported operators": {filter: squirrel.Expr("id % 2 = 0")},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []Record
+			err := sqlice.Filter([]Record{}, &output, test.filter)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestFilter_ExprEvaluationErrors(t *testing.T) {
+	tests := map[string]struct {
+		filter squirrel.Sqlizer
+	}{
+		"division by zero":   {filter: squirrel.Expr("id / 0 = 1")},
+		"invalid arithmetic": {filter: squirrel.Expr("name + 1 = 2")},
+		"invalid concat":     {filter: squirrel.Expr("name || id = 'a1'")},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []Record
+			err := sqlice.Filter([]Record{{ID: 1, Name: "a"}}, &output, test.filter)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func ExampleFilter_expr() {
+	type FooBar struct {
+		Name  string
+		Age   int
+		Email *string
+	}
+	email := "bob@example.com"
+	input := []FooBar{
+		{Name: "alice", Age: 17},
+		{Name: "bob", Age: 25, Email: &email},
+		{Name: "carol", Age: 42},
+	}
+	var output []FooBar
+
+	err := sqlice.Filter(input, &output, squirrel.Expr("age BETWEEN ? AND ? AND (email IS NOT NULL OR name IN ('alice', 'carol'))", 18, 65))
+	if err != nil {
+		panic(err)
+	}
+	for _, v := range output {
+		fmt.Println(v.Name)
+	}
+	// Output:
+	// bob
+	// carol
+}
@@ -0,0 +1,88 @@
+package sqlice
+
+import (
+	"strings"
+	"sync"
+)
+
+const defaultTagName = "db"
+
+var defaultFilterer = NewFilterer()
+
+// Filterer filters slices, using its configuration to map struct fields to the column names used in filters.
+// Like sqlx's reflectx.Mapper, a field is named by its tag if it has one, and otherwise by the result of
+// passing its name to the name mapper. The fields of each struct type are resolved once and cached by the
+// Filterer, so a Filterer should be reused rather than created for each call. Filterers are safe for
+// concurrent use
+type Filterer struct {
+	tagName       string
+	nameMapper    func(string) string
+	caseSensitive bool
+	strict        bool
+	catalog       *Catalog
+
+	// fieldCache maps struct types to their cachedFields
+	fieldCache sync.Map
+}
+
+// Option configures a Filterer
+type Option func(*Filterer)
+
+// NewFilterer creates a Filterer. Without any options, it behaves like the package level functions: fields
+// are named by their "db" tag or their lowercased name, and are matched case insensitively
+func NewFilterer(opts ...Option) *Filterer {
+	f := &Filterer{
+		tagName:    defaultTagName,
+		nameMapper: strings.ToLower,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// WithTagName sets the struct tag used to name fields, e.g. "json"
+func WithTagName(tagName string) Option {
+	return func(f *Filterer) {
+		f.tagName = tagName
+	}
+}
+
+// WithNameMapper sets the function used to name fields that have no tag. It is given the name of the struct
+// field, e.g. a function converting "CreatedAt" to "created_at" allows filtering on snake_case columns
+func WithNameMapper(mapper func(string) string) Option {
+	return func(f *Filterer) {
+		f.nameMapper = mapper
+	}
+}
+
+// WithCaseSensitive sets whether the names used in filters must match the case of the field names exactly.
+// Filterers are case insensitive by default
+func WithCaseSensitive(caseSensitive bool) Option {
+	return func(f *Filterer) {
+		f.caseSensitive = caseSensitive
+	}
+}
+
+// WithStrict sets whether filters containing Sqlizers that can't be evaluated are rejected. By default such
+// Sqlizers match every element; in strict mode validating the filter fails with an *UnsupportedFilterError
+func WithStrict(strict bool) Option {
+	return func(f *Filterer) {
+		f.strict = strict
+	}
+}
+
+// WithCatalog sets the catalog of tables used to run subqueries, such as squirrel.Eq{"id": squirrel.Select(...)}
+func WithCatalog(catalog *Catalog) Option {
+	return func(f *Filterer) {
+		f.catalog = catalog
+	}
+}
+
+// normalizeName returns the form of name used to look up fields
+func (f *Filterer) normalizeName(name string) string {
+	if f.caseSensitive {
+		return name
+	}
+	return strings.ToLower(name)
+}
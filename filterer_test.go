@@ -0,0 +1,226 @@
+package sqlice_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"unicode"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+func snakeCase(name string) string {
+	var b strings.Builder
+	var last rune
+	for _, r := range name {
+		if unicode.IsUpper(r) && unicode.IsLower(last) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+		last = r
+	}
+	return b.String()
+}
+
+func TestFilterer_Filter(t *testing.T) {
+	type FooBar struct {
+		UserID    int
+		FirstName string `json:"name,omitempty"`
+		LastName  string `db:"surname" json:"-"`
+	}
+	input := []FooBar{
+		{UserID: 1, FirstName: "a", LastName: "x"},
+		{UserID: 2, FirstName: "b", LastName: "y"},
+		{UserID: 3, FirstName: "c", LastName: "z"},
+	}
+
+	tests := map[string]struct {
+		filterer       *sqlice.Filterer
+		filter         squirrel.Sqlizer
+		expectedOutput []FooBar
+	}{
+		"default": {
+			filterer:       sqlice.NewFilterer(),
+			filter:         squirrel.Eq{"USERID": 1, "surname": "x"},
+			expectedOutput: []FooBar{input[0]},
+		},
+		"tag name": {
+			filterer:       sqlice.NewFilterer(sqlice.WithTagName("json")),
+			filter:         squirrel.Gt{"name": "a", "userid": 2},
+			expectedOutput: []FooBar{input[2]},
+		},
+		"name mapper": {
+			filterer:       sqlice.NewFilterer(sqlice.WithNameMapper(snakeCase)),
+			filter:         squirrel.Or{squirrel.Eq{"user_id": 1}, squirrel.Eq{"first_name": "b"}},
+			expectedOutput: []FooBar{input[0], input[1]},
+		},
+		"case sensitive": {
+			filterer:       sqlice.NewFilterer(sqlice.WithCaseSensitive(true), sqlice.WithNameMapper(func(s string) string { return s })),
+			filter:         squirrel.LtOrEq{"UserID": 2},
+			expectedOutput: []FooBar{input[0], input[1]},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []FooBar
+			err := test.filterer.Filter(input, &output, test.filter)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if !reflect.DeepEqual(output, test.expectedOutput) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestFilterer_ErrorConditions(t *testing.T) {
+	tests := map[string]struct {
+		filterer      *sqlice.Filterer
+		input, output interface{}
+		filter        squirrel.Sqlizer
+	}{
+		"case sensitive mismatch": {
+			filterer: sqlice.NewFilterer(sqlice.WithCaseSensitive(true)),
+			input:    []struct{ A int }{},
+			output:   &[]struct{ A int }{},
+			filter:   squirrel.Eq{"A": 1},
+		},
+		"skipped by tag": {
+			filterer: sqlice.NewFilterer(sqlice.WithTagName("json")),
+			input: []struct {
+				A int `json:"-"`
+			}{},
+			output: &[]struct {
+				A int `json:"-"`
+			}{},
+			filter: squirrel.Eq{"A": 1},
+		},
+		"duplicate mapped names": {
+			filterer: sqlice.NewFilterer(sqlice.WithNameMapper(snakeCase)),
+			input: []struct {
+				UserID  int
+				User_ID int
+			}{},
+			output: &[]struct {
+				UserID  int
+				User_ID int
+			}{},
+			filter: squirrel.Eq{"user_id": 1},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.filterer.Filter(test.input, test.output, test.filter)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestFilterer_Strict(t *testing.T) {
+	type FooBar struct {
+		A int
+	}
+	input := []FooBar{{A: 1}, {A: 2}}
+	unsupported := squirrel.ConcatExpr("a = ", 1)
+
+	tests := map[string]struct {
+		filter       squirrel.Sqlizer
+		expectedType reflect.Type
+		expectedPath string
+	}{
+		"root": {
+			filter:       unsupported,
+			expectedType: reflect.TypeOf(unsupported),
+			expectedPath: "",
+		},
+		"nested": {
+			filter:       squirrel.And{squirrel.Eq{"A": 1}, squirrel.Or{unsupported}},
+			expectedType: reflect.TypeOf(unsupported),
+			expectedPath: "And[1].Or[0]",
+		},
+		"case": {
+			filter:       squirrel.Or{squirrel.Case("a").When("1", "true")},
+			expectedType: reflect.TypeOf(squirrel.Case()),
+			expectedPath: "Or[0]",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []FooBar
+			err := sqlice.NewFilterer(sqlice.WithStrict(true)).Filter(input, &output, test.filter)
+			var unsupportedErr *sqlice.UnsupportedFilterError
+			if !errors.As(err, &unsupportedErr) {
+				t.Fatal("Expected an UnsupportedFilterError, got:", err)
+			}
+			if unsupportedErr.Path != test.expectedPath {
+				t.Errorf("Expected path '%v' got '%v'", test.expectedPath, unsupportedErr.Path)
+			}
+			if unsupportedErr.Type != test.expectedType {
+				t.Errorf("Expected type '%v' got '%v'", test.expectedType, unsupportedErr.Type)
+			}
+
+			// without strict mode, unsupported filters match everything
+			if err := sqlice.NewFilterer().Filter(input, &output, test.filter); err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+		})
+	}
+}
+
+func TestFilterer_Concurrent(t *testing.T) {
+	type FooBar struct {
+		A int
+	}
+	filterer := sqlice.NewFilterer()
+	input := []FooBar{{A: 1}, {A: 2}, {A: 3}}
+	expectedOutput := []FooBar{{A: 2}, {A: 3}}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var output []FooBar
+			if err := filterer.Filter(input, &output, squirrel.Gt{"A": 1}); err != nil {
+				errs <- err
+				return
+			}
+			if !reflect.DeepEqual(output, expectedOutput) {
+				errs <- fmt.Errorf("expected '%v' got '%v'", expectedOutput, output)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func ExampleNewFilterer() {
+	type FooBar struct {
+		CreatedBy string
+		UpdatedBy string `json:"editor"`
+	}
+	input := []FooBar{
+		{CreatedBy: "alice", UpdatedBy: "bob"},
+		{CreatedBy: "bob", UpdatedBy: "alice"},
+	}
+	var output []FooBar
+
+	filterer := sqlice.NewFilterer(sqlice.WithTagName("json"), sqlice.WithNameMapper(snakeCase))
+	err := filterer.Filter(input, &output, squirrel.Eq{"created_by": "bob", "editor": "alice"})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(output)
+	// Output: [{bob alice}]
+}
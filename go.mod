@@ -1,5 +1,10 @@
 module github.com/pixelrazor/sqlice
 
-go 1.16
+go 1.18
 
-require github.com/Masterminds/squirrel v1.5.0
+require (
+	github.com/Masterminds/squirrel v1.5.0
+	github.com/lann/builder v0.0.0-20180802200727-47ae307949d0
+)
+
+require github.com/lann/ps v0.0.0-20150810152359-62de8c46ede0 // indirect
@@ -0,0 +1,206 @@
+package sqlice
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// JoinType is the kind of a join
+type JoinType int
+
+const (
+	// InnerJoin keeps the combinations of rows matching the join condition
+	InnerJoin JoinType = iota
+	// LeftJoin also keeps the rows joined so far that match no row of the joined table
+	LeftJoin
+	// RightJoin also keeps the rows of the joined table that match no row joined so far
+	RightJoin
+)
+
+// String returns the SQL keywords of the join type
+func (t JoinType) String() string {
+	switch t {
+	case InnerJoin:
+		return "INNER JOIN"
+	case LeftJoin:
+		return "LEFT JOIN"
+	case RightJoin:
+		return "RIGHT JOIN"
+	default:
+		return fmt.Sprintf("JoinType(%d)", int(t))
+	}
+}
+
+// Table is a slice of structs used as a table under an alias, e.g. Table{Alias: "u", Rows: users}
+type Table struct {
+	Alias string
+	Rows  interface{}
+}
+
+// JoinClause joins a table to the rows joined so far
+type JoinClause struct {
+	Type  JoinType
+	Table Table
+	// On is the join condition. Its columns are qualified by the table aliases, e.g.
+	// squirrel.Expr("u.id = o.user_id"). If it's nil, every combination of rows is joined
+	On squirrel.Sqlizer
+}
+
+// Join joins the slices of the tables, like FROM from followed by each join, and stores the combined rows
+// matching the filter in output. Output must be a pointer to a slice of structs with a field for each table,
+// named or tagged by the table's alias and holding a row of the table, e.g.
+//
+//	type UserOrder struct {
+//		User  User   `db:"u"`
+//		Order *Order `db:"o"`
+//	}
+//
+// The columns of the tables are referred to by the alias, like "u.id", in both the join conditions and the
+// filter. Tables that may have no matching row, like the joined table of a left join, must use pointer fields,
+// which are nil for the missing rows so their columns are NULL
+func Join(output interface{}, filter squirrel.Sqlizer, from Table, joins ...JoinClause) error {
+	return defaultFilterer.Join(output, filter, from, joins...)
+}
+
+// Join joins the slices of the tables and stores the combined rows matching the filter in output. See the
+// package level Join for details
+func (f *Filterer) Join(output interface{}, filter squirrel.Sqlizer, from Table, joins ...JoinClause) error {
+	outVal, err := getOutputValue(output)
+	if err != nil {
+		return fmt.Errorf("failed to validate in/out params: %w", err)
+	}
+	rowType := outVal.Type().Elem()
+	if rowType.Kind() != reflect.Struct {
+		return errors.New("output slice type is not a struct")
+	}
+	fields, err := f.getFields(rowType)
+	if err != nil {
+		return fmt.Errorf("unable to use output type: %w", err)
+	}
+
+	table, err := f.lookupTable(fields, from, false)
+	if err != nil {
+		return err
+	}
+	result := reflect.MakeSlice(outVal.Type(), table.rows.Len(), table.rows.Len())
+	for i := 0; i < table.rows.Len(); i++ {
+		table.set(result.Index(i), i)
+	}
+	tables := []joinedTable{table}
+	for _, join := range joins {
+		if result, table, err = f.join(result, fields, tables, join); err != nil {
+			return err
+		}
+		tables = append(tables, table)
+	}
+	if result, err = f.filter(result, filter); err != nil {
+		return err
+	}
+	outVal.Set(result)
+	return nil
+}
+
+// joinedTable is a table with the field of the combined rows holding its rows
+type joinedTable struct {
+	alias string
+	rows  reflect.Value
+	field fieldInfo
+}
+
+// set stores the i-th row of the table in the field of row
+func (t joinedTable) set(row reflect.Value, i int) {
+	dest := allocFieldByIndex(row, t.field.Index)
+	src := t.rows.Index(i)
+	if dest.Kind() == reflect.Ptr {
+		// copy, so the output doesn't share the input's rows
+		ptr := reflect.New(src.Type())
+		ptr.Elem().Set(src)
+		src = ptr
+	}
+	dest.Set(src)
+}
+
+// lookupTable validates the table against the combined row type. nullable tables must have pointer fields
+func (f *Filterer) lookupTable(fields map[string]fieldInfo, table Table, nullable bool) (joinedTable, error) {
+	inVal, err := getInputValue(table.Rows)
+	if err != nil {
+		return joinedTable{}, fmt.Errorf("unable to use table '%v': %w", table.Alias, err)
+	}
+	field, ok := fields[f.normalizeName(table.Alias)]
+	if !ok {
+		return joinedTable{}, fmt.Errorf("output struct has no field named '%v'", table.Alias)
+	}
+	rowType := inVal.Type().Elem()
+	switch {
+	case field.Type == reflect.PtrTo(rowType):
+	case field.Type == rowType && !nullable:
+	case field.Type == rowType:
+		return joinedTable{}, fmt.Errorf("field '%v' must be a pointer, since its rows may be missing", table.Alias)
+	default:
+		return joinedTable{}, fmt.Errorf("field '%v' is of type %v, not %v", table.Alias, field.Type, rowType)
+	}
+	return joinedTable{alias: table.Alias, rows: inVal, field: field}, nil
+}
+
+// join returns a new slice of the rows of inVal, which hold the rows of tables, joined with the table of the
+// join clause
+func (f *Filterer) join(inVal reflect.Value, fields map[string]fieldInfo, tables []joinedTable, join JoinClause) (reflect.Value, joinedTable, error) {
+	if join.Type != InnerJoin && join.Type != LeftJoin && join.Type != RightJoin {
+		return reflect.Value{}, joinedTable{}, fmt.Errorf("unsupported join type %v", join.Type)
+	}
+	table, err := f.lookupTable(fields, join.Table, join.Type == LeftJoin)
+	if err != nil {
+		return reflect.Value{}, joinedTable{}, err
+	}
+	if join.Type == RightJoin {
+		// the rows joined so far are missing for unmatched rows of the table
+		for _, joined := range tables {
+			if joined.field.Type.Kind() != reflect.Ptr {
+				return reflect.Value{}, joinedTable{}, fmt.Errorf("field '%v' must be a pointer, since its rows may be missing", joined.alias)
+			}
+		}
+	}
+	on := matchAlways
+	if join.On != nil {
+		if on, err = f.compileFilter(join.On, fields, ""); err != nil {
+			return reflect.Value{}, joinedTable{}, fmt.Errorf("unable to use join condition of '%v': %w", join.Table.Alias, err)
+		}
+	}
+
+	rowType := inVal.Type().Elem()
+	outVal := reflect.MakeSlice(inVal.Type(), 0, inVal.Len())
+	matchedRows := make([]bool, table.rows.Len())
+	for i := 0; i < inVal.Len(); i++ {
+		matched := false
+		for j := 0; j < table.rows.Len(); j++ {
+			row := reflect.New(rowType).Elem()
+			row.Set(inVal.Index(i))
+			table.set(row, j)
+			ok, err := on(row)
+			if err != nil {
+				return reflect.Value{}, joinedTable{}, fmt.Errorf("unable to apply join condition of '%v': %w", join.Table.Alias, err)
+			}
+			if ok {
+				outVal = reflect.Append(outVal, row)
+				matched = true
+				matchedRows[j] = true
+			}
+		}
+		if !matched && join.Type == LeftJoin {
+			outVal = reflect.Append(outVal, inVal.Index(i))
+		}
+	}
+	if join.Type == RightJoin {
+		for j, matched := range matchedRows {
+			if !matched {
+				row := reflect.New(rowType).Elem()
+				table.set(row, j)
+				outVal = reflect.Append(outVal, row)
+			}
+		}
+	}
+	return outVal, table, nil
+}
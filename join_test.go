@@ -0,0 +1,211 @@
+package sqlice_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+type joinUser struct {
+	ID   int
+	Name string
+}
+
+type joinOrder struct {
+	ID     int
+	UserID int `db:"user_id"`
+	Amount float64
+}
+
+func joinTables() (sqlice.Table, sqlice.Table) {
+	users := []joinUser{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}, {ID: 3, Name: "carol"}}
+	orders := []joinOrder{{ID: 10, UserID: 1, Amount: 5}, {ID: 11, UserID: 1, Amount: 20}, {ID: 12, UserID: 2, Amount: 15}, {ID: 13, UserID: 4, Amount: 1}}
+	return sqlice.Table{Alias: "u", Rows: users}, sqlice.Table{Alias: "o", Rows: orders}
+}
+
+// joinPairs returns the user and order IDs of the rows, with 0 for missing rows
+func joinPairs(rows []userOrder) [][2]int {
+	pairs := [][2]int{}
+	for _, row := range rows {
+		var pair [2]int
+		if row.User != nil {
+			pair[0] = row.User.ID
+		}
+		if row.Order != nil {
+			pair[1] = row.Order.ID
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+type userOrder struct {
+	User  *joinUser  `db:"u"`
+	Order *joinOrder `db:"o"`
+}
+
+func TestJoin(t *testing.T) {
+	on := squirrel.Expr("u.id = o.user_id")
+	tests := map[string]struct {
+		joinType      sqlice.JoinType
+		on            squirrel.Sqlizer
+		filter        squirrel.Sqlizer
+		expectedPairs [][2]int
+	}{
+		"inner": {
+			joinType:      sqlice.InnerJoin,
+			on:            on,
+			expectedPairs: [][2]int{{1, 10}, {1, 11}, {2, 12}},
+		},
+		"left": {
+			joinType:      sqlice.LeftJoin,
+			on:            on,
+			expectedPairs: [][2]int{{1, 10}, {1, 11}, {2, 12}, {3, 0}},
+		},
+		"right": {
+			joinType:      sqlice.RightJoin,
+			on:            on,
+			expectedPairs: [][2]int{{1, 10}, {1, 11}, {2, 12}, {0, 13}},
+		},
+		"filtered": {
+			joinType:      sqlice.InnerJoin,
+			on:            on,
+			filter:        squirrel.And{squirrel.Gt{"o.amount": 10.0}, squirrel.Like{"u.name": "a%"}},
+			expectedPairs: [][2]int{{1, 11}},
+		},
+		"anti join": {
+			joinType:      sqlice.LeftJoin,
+			on:            on,
+			filter:        squirrel.Eq{"o.id": nil},
+			expectedPairs: [][2]int{{3, 0}},
+		},
+		"condition": {
+			joinType:      sqlice.InnerJoin,
+			on:            squirrel.And{on, squirrel.GtOrEq{"o.amount": 15.0}},
+			expectedPairs: [][2]int{{1, 11}, {2, 12}},
+		},
+		"cross": {
+			joinType:      sqlice.InnerJoin,
+			filter:        squirrel.Eq{"u.id": 3},
+			expectedPairs: [][2]int{{3, 10}, {3, 11}, {3, 12}, {3, 13}},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			users, orders := joinTables()
+			var output []userOrder
+			err := sqlice.Join(&output, test.filter, users, sqlice.JoinClause{Type: test.joinType, Table: orders, On: test.on})
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if pairs := joinPairs(output); !reflect.DeepEqual(pairs, test.expectedPairs) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedPairs, pairs)
+			}
+		})
+	}
+}
+
+func TestJoin_MultipleTables(t *testing.T) {
+	type item struct {
+		OrderID int `db:"order_id"`
+		SKU     string
+	}
+	type row struct {
+		User  joinUser
+		Order joinOrder `db:"o"`
+		Item  *item     `db:"i"`
+	}
+	users, orders := joinTables()
+	items := sqlice.Table{Alias: "i", Rows: []item{{OrderID: 10, SKU: "a"}, {OrderID: 10, SKU: "b"}, {OrderID: 12, SKU: "c"}}}
+
+	var output []row
+	err := sqlice.Join(&output, squirrel.NotEq{"user.name": "bob"}, sqlice.Table{Alias: "user", Rows: users.Rows},
+		sqlice.JoinClause{Type: sqlice.InnerJoin, Table: orders, On: squirrel.Expr("user.id = o.user_id")},
+		sqlice.JoinClause{Type: sqlice.LeftJoin, Table: items, On: squirrel.Expr("o.id = i.order_id")},
+	)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	var got []string
+	for _, r := range output {
+		sku := "NULL"
+		if r.Item != nil {
+			sku = r.Item.SKU
+		}
+		got = append(got, fmt.Sprintf("%v:%v:%v", r.User.Name, r.Order.ID, sku))
+	}
+	expected := []string{"alice:10:a", "alice:10:b", "alice:11:NULL"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected '%v' got '%v'", expected, got)
+	}
+}
+
+func TestJoin_ErrorConditions(t *testing.T) {
+	type valueRows struct {
+		User  joinUser  `db:"u"`
+		Order joinOrder `db:"o"`
+	}
+	type wrongType struct {
+		User  joinUser `db:"u"`
+		Order joinUser `db:"o"`
+	}
+	users, orders := joinTables()
+	on := squirrel.Expr("u.id = o.user_id")
+	tests := map[string]struct {
+		output interface{}
+		from   sqlice.Table
+		join   sqlice.JoinClause
+	}{
+		"output not pointer":    {output: []userOrder{}, from: users, join: sqlice.JoinClause{Table: orders, On: on}},
+		"bad output":            {output: &[]int{}, from: users, join: sqlice.JoinClause{Table: orders, On: on}},
+		"bad table":             {output: &[]userOrder{}, from: sqlice.Table{Alias: "u", Rows: 1}, join: sqlice.JoinClause{Table: orders, On: on}},
+		"unknown alias":         {output: &[]userOrder{}, from: sqlice.Table{Alias: "x", Rows: users.Rows}, join: sqlice.JoinClause{Table: orders, On: on}},
+		"wrong type":            {output: &[]wrongType{}, from: users, join: sqlice.JoinClause{Table: orders, On: on}},
+		"left join value":       {output: &[]valueRows{}, from: users, join: sqlice.JoinClause{Type: sqlice.LeftJoin, Table: orders, On: on}},
+		"right join value":      {output: &[]valueRows{}, from: users, join: sqlice.JoinClause{Type: sqlice.RightJoin, Table: orders, On: on}},
+		"unknown column":        {output: &[]userOrder{}, from: users, join: sqlice.JoinClause{Table: orders, On: squirrel.Expr("u.id = o.foo")}},
+		"unqualified column":    {output: &[]userOrder{}, from: users, join: sqlice.JoinClause{Table: orders, On: squirrel.Expr("id = user_id")}},
+		"unsupported join type": {output: &[]userOrder{}, from: users, join: sqlice.JoinClause{Type: 5, Table: orders, On: on}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := sqlice.Join(test.output, nil, test.from, test.join)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func ExampleJoin() {
+	type User struct {
+		ID   int
+		Name string
+	}
+	type Order struct {
+		ID     int
+		UserID int `db:"user_id"`
+	}
+	type UserOrder struct {
+		User  User   `db:"u"`
+		Order *Order `db:"o"`
+	}
+	users := []User{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}}
+	orders := []Order{{ID: 10, UserID: 1}}
+
+	var output []UserOrder
+	err := sqlice.Join(&output, nil, sqlice.Table{Alias: "u", Rows: users},
+		sqlice.JoinClause{Type: sqlice.LeftJoin, Table: sqlice.Table{Alias: "o", Rows: orders}, On: squirrel.Expr("u.id = o.user_id")})
+	if err != nil {
+		panic(err)
+	}
+	for _, row := range output {
+		fmt.Println(row.User.Name, row.Order)
+	}
+	// Output:
+	// alice &{10 1}
+	// bob <nil>
+}
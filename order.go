@@ -0,0 +1,189 @@
+package sqlice
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Sort sorts the slice in place by the SQL style ORDER BY terms, e.g. "created_at DESC" or
+// "name ASC NULLS LAST". Terms may hold several comma separated keys, and name columns like filters do. The
+// sort is stable, and values are ordered like Lt and Gt compare them. Like in Postgres, NULLs sort last, or
+// first for DESC terms, unless NULLS FIRST or NULLS LAST is given
+func Sort(slice interface{}, orderBy ...string) error {
+	return defaultFilterer.Sort(slice, orderBy...)
+}
+
+// Sort sorts the slice in place by the ORDER BY terms. See the package level Sort for details
+func (f *Filterer) Sort(slice interface{}, orderBy ...string) error {
+	if slice == nil {
+		return errors.New("slice is nil")
+	}
+	sliceVal := reflect.ValueOf(slice)
+	if sliceVal.Kind() != reflect.Slice {
+		return errors.New("slice is not a slice")
+	}
+	if sliceVal.Type().Elem().Kind() != reflect.Struct {
+		return errors.New("slice type is not sort-able")
+	}
+	if len(orderBy) == 0 {
+		return nil
+	}
+	terms, err := parseOrderBy(strings.Join(orderBy, ", "), nil)
+	if err != nil {
+		return fmt.Errorf("unable to parse order by: %w", err)
+	}
+	fields, err := f.getFields(sliceVal.Type().Elem())
+	if err != nil {
+		return fmt.Errorf("unable to use input type: %w", err)
+	}
+	compiled, err := f.compileOrderBy(terms, fields)
+	if err != nil {
+		return fmt.Errorf("unable to use order by: %w", err)
+	}
+	sorted, err := sortSlice(sliceVal, compiled)
+	if err != nil {
+		return err
+	}
+	reflect.Copy(sliceVal, sorted)
+	return nil
+}
+
+// orderNode is a parsed ORDER BY term
+type orderNode struct {
+	expr exprNode
+	desc bool
+	// nullsFirst is set if NULLs sort before other values. Like in Postgres, NULLs are larger than any other
+	// value unless NULLS FIRST or NULLS LAST is given
+	nullsFirst bool
+}
+
+// parseOrderBy parses the comma separated terms of an ORDER BY clause, e.g. "name DESC NULLS LAST, id"
+func parseOrderBy(sql string, args []interface{}) ([]orderNode, error) {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, args: args}
+	terms, err := p.parseOrderTerms()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return terms, nil
+}
+
+func (p *parser) parseOrderTerms() ([]orderNode, error) {
+	var terms []orderNode
+	for {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		term := orderNode{expr: expr}
+		if direction, ok := p.acceptKeyword("ASC", "DESC"); ok {
+			term.desc = direction == "DESC"
+		}
+		term.nullsFirst = term.desc
+		if _, ok := p.acceptKeyword("NULLS"); ok {
+			position, ok := p.acceptKeyword("FIRST", "LAST")
+			if !ok {
+				return nil, p.unexpected("FIRST or LAST")
+			}
+			term.nullsFirst = position == "FIRST"
+		}
+		terms = append(terms, term)
+		if !p.acceptSymbol(",") {
+			return terms, nil
+		}
+	}
+}
+
+// orderTerm is a compiled ORDER BY term
+type orderTerm struct {
+	eval       evaluator
+	desc       bool
+	nullsFirst bool
+}
+
+func (f *Filterer) compileOrderBy(terms []orderNode, fields map[string]fieldInfo) ([]orderTerm, error) {
+	output := make([]orderTerm, 0, len(terms))
+	for _, term := range terms {
+		eval, err := f.compileNode(term.expr, fields)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, orderTerm{eval: eval, desc: term.desc, nullsFirst: term.nullsFirst})
+	}
+	return output, nil
+}
+
+// sortSlice returns a new slice of the elements of inVal stably sorted by the terms
+func sortSlice(inVal reflect.Value, terms []orderTerm) (reflect.Value, error) {
+	order := make([]int, inVal.Len())
+	for i := range order {
+		order[i] = i
+	}
+	if err := sortIndexes(inVal, order, terms); err != nil {
+		return reflect.Value{}, err
+	}
+
+	outVal := reflect.MakeSlice(inVal.Type(), len(order), len(order))
+	for i, index := range order {
+		outVal.Index(i).Set(inVal.Index(index))
+	}
+	return outVal, nil
+}
+
+// sortIndexes stably sorts the indexes of elements of inVal by the terms
+func sortIndexes(inVal reflect.Value, indexes []int, terms []orderTerm) error {
+	// evaluate the sort keys once per element, rather than once per comparison
+	keys := make(map[int][]interface{}, len(indexes))
+	for _, i := range indexes {
+		keys[i] = make([]interface{}, len(terms))
+		for j, term := range terms {
+			key, err := term.eval(inVal.Index(i))
+			if err != nil {
+				return fmt.Errorf("unable to sort: %w", err)
+			}
+			keys[i][j] = key
+		}
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		for j, term := range terms {
+			if cmp := compareOrder(keys[indexes[a]][j], keys[indexes[b]][j], term); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+// compareOrder returns -1 if a sorts before b for the term, 1 if it sorts after, and 0 if they're equal or
+// can't be compared
+func compareOrder(a, b interface{}, term orderTerm) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil || b == nil:
+		if (a == nil) == term.nullsFirst {
+			return -1
+		}
+		return 1
+	}
+	cmp := 0
+	if less, ok := sqlCompare(a, b, opLT); ok && less {
+		cmp = -1
+	} else if greater, ok := sqlCompare(a, b, opGT); ok && greater {
+		cmp = 1
+	}
+	if term.desc {
+		return -cmp
+	}
+	return cmp
+}
@@ -0,0 +1,124 @@
+package sqlice_test
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pixelrazor/sqlice"
+)
+
+func TestSort(t *testing.T) {
+	base := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	input := []Record{
+		{ID: 1, Name: "bob", Nickname: sql.NullString{String: "bobby", Valid: true}, Logins: 2, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: 2, Name: "alice", CreatedAt: base, Active: true},
+		{ID: 3, Name: "bob", Nickname: sql.NullString{String: "b", Valid: true}, Logins: 1, CreatedAt: base.Add(time.Hour)},
+		{ID: 4, Name: "Carol", Logins: 3, CreatedAt: base.Add(3 * time.Hour), Active: true},
+	}
+
+	tests := map[string]struct {
+		orderBy     []string
+		expectedIDs []int
+	}{
+		"none": {
+			orderBy:     nil,
+			expectedIDs: []int{1, 2, 3, 4},
+		},
+		"case sensitive": {
+			orderBy:     []string{"name"},
+			expectedIDs: []int{4, 2, 1, 3},
+		},
+		"tag name": {
+			orderBy:     []string{"login_count"},
+			expectedIDs: []int{2, 3, 1, 4},
+		},
+		"desc": {
+			orderBy:     []string{"created_at DESC"},
+			expectedIDs: []int{4, 1, 3, 2},
+		},
+		"multiple terms": {
+			orderBy:     []string{"name DESC", "id DESC"},
+			expectedIDs: []int{3, 1, 2, 4},
+		},
+		"comma separated": {
+			orderBy:     []string{"name asc, created_at"},
+			expectedIDs: []int{4, 2, 3, 1},
+		},
+		"valuer nulls last": {
+			orderBy:     []string{"nickname"},
+			expectedIDs: []int{3, 1, 2, 4},
+		},
+		"valuer nulls first": {
+			orderBy:     []string{"nickname ASC NULLS FIRST"},
+			expectedIDs: []int{2, 4, 3, 1},
+		},
+		"desc nulls last": {
+			orderBy:     []string{"nickname DESC NULLS LAST"},
+			expectedIDs: []int{1, 3, 2, 4},
+		},
+		"bool": {
+			orderBy:     []string{"active DESC"},
+			expectedIDs: []int{2, 4, 1, 3},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			slice := append([]Record(nil), input...)
+			err := sqlice.Sort(slice, test.orderBy...)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if ids := recordIDs(slice); !reflect.DeepEqual(ids, test.expectedIDs) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestSort_ErrorConditions(t *testing.T) {
+	tests := map[string]struct {
+		slice   interface{}
+		orderBy []string
+	}{
+		"nil slice":        {slice: nil, orderBy: []string{"id"}},
+		"not a slice":      {slice: Record{}, orderBy: []string{"id"}},
+		"pointer to slice": {slice: &[]Record{}, orderBy: []string{"id"}},
+		"not structs":      {slice: []int{}, orderBy: []string{"id"}},
+		"unknown column":   {slice: []Record{}, orderBy: []string{"foo"}},
+		"bad direction":    {slice: []Record{}, orderBy: []string{"id DOWN"}},
+		"bad nulls":        {slice: []Record{}, orderBy: []string{"id NULLS MIDDLE"}},
+		"empty term":       {slice: []Record{}, orderBy: []string{"id", ""}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := sqlice.Sort(test.slice, test.orderBy...)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func ExampleSort() {
+	type FooBar struct {
+		Name string
+		Age  *int
+	}
+	age := func(n int) *int { return &n }
+	values := []FooBar{{Name: "alice", Age: age(30)}, {Name: "bob"}, {Name: "carol", Age: age(42)}}
+
+	err := sqlice.Sort(values, "age DESC NULLS LAST", "name")
+	if err != nil {
+		panic(err)
+	}
+	for _, v := range values {
+		fmt.Println(v.Name)
+	}
+	// Output:
+	// carol
+	// alice
+	// bob
+}
@@ -0,0 +1,134 @@
+package sqlice
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Paginate filters the input slice, then stores the page of at most limit matching elements after the first
+// offset of them in output, like LIMIT and OFFSET. Output must be a pointer to a slice of identical type to
+// input
+func Paginate(input, output interface{}, filter squirrel.Sqlizer, offset, limit uint64) error {
+	return defaultFilterer.Paginate(input, output, filter, offset, limit)
+}
+
+// Paginate filters the input slice and stores a page of the results in output. See the package level
+// Paginate for details
+func (f *Filterer) Paginate(input, output interface{}, filter squirrel.Sqlizer, offset, limit uint64) error {
+	inVal, outVal, err := getParamValues(input, output)
+	if err != nil {
+		return fmt.Errorf("failed to validate in/out params: %w", err)
+	}
+	result, err := f.filter(inVal, filter)
+	if err != nil {
+		return err
+	}
+	outVal.Set(limitSlice(result, offset, &limit))
+	return nil
+}
+
+// Keyset is a Sqlizer for keyset (cursor) pagination. It renders the row value comparison selecting the rows
+// after the cursor, e.g. "(created_at, id) > (?, ?)", so the same Keyset can be used in database queries and
+// with sqlice. Queries should be ordered by OrderBy
+type Keyset struct {
+	// Columns are the columns the rows are ordered by. Together they must uniquely identify a row
+	Columns []string
+	// After holds the values of Columns for the last row of the previous page. If it's empty, the Keyset
+	// selects the first page
+	After []interface{}
+	// Desc selects rows in descending order, rendering "<" instead of ">"
+	Desc bool
+}
+
+// ToSql renders the row value comparison. It returns an empty string for the first page
+func (k Keyset) ToSql() (string, []interface{}, error) {
+	if len(k.Columns) == 0 {
+		return "", nil, errors.New("keyset has no columns")
+	}
+	if len(k.After) == 0 {
+		return "", nil, nil
+	}
+	if len(k.After) != len(k.Columns) {
+		return "", nil, fmt.Errorf("keyset has %d columns but %d values", len(k.Columns), len(k.After))
+	}
+	op := ">"
+	if k.Desc {
+		op = "<"
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(k.After)), ", ")
+	sql := fmt.Sprintf("(%v) %v (%v)", strings.Join(k.Columns, ", "), op, placeholders)
+	return sql, k.After, nil
+}
+
+// OrderBy returns the ORDER BY terms the Keyset pages through, e.g. for SelectBuilder.OrderBy
+func (k Keyset) OrderBy() []string {
+	terms := make([]string, len(k.Columns))
+	for i, column := range k.Columns {
+		terms[i] = column
+		if k.Desc {
+			terms[i] += " DESC"
+		}
+	}
+	return terms
+}
+
+// PaginateKeyset filters the input slice, then stores the first limit matching elements after the keyset's
+// cursor in output, ordered by the keyset's columns. It returns the cursor of the next page, holding the
+// column values of the last element, or nil if there are no more pages. Output must be a pointer to a slice
+// of identical type to input
+func PaginateKeyset(input, output interface{}, filter squirrel.Sqlizer, keyset Keyset, limit uint64) ([]interface{}, error) {
+	return defaultFilterer.PaginateKeyset(input, output, filter, keyset, limit)
+}
+
+// PaginateKeyset stores the page of the input slice after the keyset's cursor in output. See the package
+// level PaginateKeyset for details
+func (f *Filterer) PaginateKeyset(input, output interface{}, filter squirrel.Sqlizer, keyset Keyset, limit uint64) ([]interface{}, error) {
+	inVal, outVal, err := getParamValues(input, output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate in/out params: %w", err)
+	}
+	if filter != nil {
+		filter = squirrel.And{filter, keyset}
+	} else {
+		filter = keyset
+	}
+	result, err := f.filter(inVal, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := f.getFields(inVal.Type().Elem())
+	if err != nil {
+		return nil, fmt.Errorf("unable to use input type: %w", err)
+	}
+	terms, err := parseOrderBy(strings.Join(keyset.OrderBy(), ", "), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to use keyset: %w", err)
+	}
+	orderTerms, err := f.compileOrderBy(terms, fields)
+	if err != nil {
+		return nil, fmt.Errorf("unable to use keyset: %w", err)
+	}
+	if result, err = sortSlice(result, orderTerms); err != nil {
+		return nil, err
+	}
+	// the elements after the page are already known, so there's only a next page if any are left
+	more := uint64(result.Len()) > limit
+	result = limitSlice(result, 0, &limit)
+	outVal.Set(result)
+
+	if !more || result.Len() == 0 {
+		return nil, nil
+	}
+	last := result.Index(result.Len() - 1)
+	next := make([]interface{}, len(orderTerms))
+	for i, term := range orderTerms {
+		if next[i], err = term.eval(last); err != nil {
+			return nil, fmt.Errorf("unable to get cursor: %w", err)
+		}
+	}
+	return next, nil
+}
@@ -0,0 +1,192 @@
+package sqlice_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+func TestPaginate(t *testing.T) {
+	tests := map[string]struct {
+		filter        squirrel.Sqlizer
+		offset, limit uint64
+		expectedIDs   []int
+	}{
+		"first page": {
+			limit:       2,
+			expectedIDs: []int{1, 2},
+		},
+		"offset": {
+			offset:      2,
+			limit:       2,
+			expectedIDs: []int{3, 4},
+		},
+		"last page": {
+			offset:      4,
+			limit:       2,
+			expectedIDs: []int{5},
+		},
+		"past end": {
+			offset:      5,
+			limit:       2,
+			expectedIDs: []int{},
+		},
+		"filtered": {
+			filter:      squirrel.Eq{"active": true},
+			offset:      1,
+			limit:       2,
+			expectedIDs: []int{2, 4},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []Record
+			err := sqlice.Paginate(records(), &output, test.filter, test.offset, test.limit)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if ids := recordIDs(output); !reflect.DeepEqual(ids, test.expectedIDs) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestKeyset_ToSql(t *testing.T) {
+	tests := map[string]struct {
+		keyset       sqlice.Keyset
+		expectedSQL  string
+		expectedArgs []interface{}
+	}{
+		"first page": {
+			keyset:      sqlice.Keyset{Columns: []string{"created_at", "id"}},
+			expectedSQL: "",
+		},
+		"ascending": {
+			keyset:       sqlice.Keyset{Columns: []string{"created_at", "id"}, After: []interface{}{"x", 1}},
+			expectedSQL:  "(created_at, id) > (?, ?)",
+			expectedArgs: []interface{}{"x", 1},
+		},
+		"descending": {
+			keyset:       sqlice.Keyset{Columns: []string{"id"}, After: []interface{}{1}, Desc: true},
+			expectedSQL:  "(id) < (?)",
+			expectedArgs: []interface{}{1},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sql, args, err := test.keyset.ToSql()
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if sql != test.expectedSQL {
+				t.Errorf("Expected '%v' got '%v'", test.expectedSQL, sql)
+			}
+			if !reflect.DeepEqual(args, test.expectedArgs) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestPaginateKeyset(t *testing.T) {
+	tests := map[string]struct {
+		filter squirrel.Sqlizer
+		desc   bool
+		limit  uint64
+		pages  [][]int
+	}{
+		"ascending": {
+			limit: 2,
+			pages: [][]int{{2, 1}, {3, 5}, {4}},
+		},
+		"descending": {
+			desc:  true,
+			limit: 2,
+			pages: [][]int{{4, 5}, {3, 1}, {2}},
+		},
+		"filtered": {
+			filter: squirrel.Expr("active"),
+			limit:  2,
+			pages:  [][]int{{2, 1}, {5, 4}},
+		},
+		"exact pages": {
+			limit: 5,
+			pages: [][]int{{2, 1, 3, 5, 4}},
+		},
+		"zero limit": {
+			limit: 0,
+			pages: [][]int{{}},
+		},
+		"single page": {
+			limit: 10,
+			pages: [][]int{{2, 1, 3, 5, 4}},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			keyset := sqlice.Keyset{Columns: []string{"created_at", "id"}, Desc: test.desc}
+			for i, expectedIDs := range test.pages {
+				var output []Record
+				next, err := sqlice.PaginateKeyset(records(), &output, test.filter, keyset, test.limit)
+				if err != nil {
+					t.Fatal("Expected no error, got:", err)
+				}
+				if ids := recordIDs(output); !reflect.DeepEqual(ids, expectedIDs) {
+					t.Errorf("Page %d: expected '%v' got '%v'", i, expectedIDs, ids)
+				}
+				if last := i == len(test.pages)-1; last != (next == nil) {
+					t.Fatalf("Page %d: unexpected next cursor '%v'", i, next)
+				}
+				keyset.After = next
+			}
+		})
+	}
+}
+
+func TestPaginateKeyset_ErrorConditions(t *testing.T) {
+	tests := map[string]struct {
+		keyset sqlice.Keyset
+	}{
+		"no columns":     {keyset: sqlice.Keyset{}},
+		"unknown column": {keyset: sqlice.Keyset{Columns: []string{"foo"}}},
+		"too few values": {keyset: sqlice.Keyset{Columns: []string{"created_at", "id"}, After: []interface{}{1}}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []Record
+			_, err := sqlice.PaginateKeyset(records(), &output, nil, test.keyset, 2)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func ExamplePaginateKeyset() {
+	type FooBar struct {
+		ID   int
+		Name string
+	}
+	input := []FooBar{{ID: 3, Name: "c"}, {ID: 1, Name: "a"}, {ID: 4, Name: "d"}, {ID: 2, Name: "b"}}
+
+	keyset := sqlice.Keyset{Columns: []string{"id"}}
+	for {
+		var page []FooBar
+		next, err := sqlice.PaginateKeyset(input, &page, nil, keyset, 3)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(page)
+		if next == nil {
+			break
+		}
+		keyset.After = next
+	}
+	// Output:
+	// [{1 a} {2 b} {3 c}]
+	// [{4 d}]
+}
@@ -0,0 +1,116 @@
+package sqlice
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Predicate is a filter compiled for a specific struct type. The filter is validated, and its fields and
+// expressions resolved, once when compiling, making predicates suited for applying the same filter many
+// times. Predicates are safe for concurrent use
+type Predicate struct {
+	typ     reflect.Type
+	matcher matcher
+}
+
+// Compile validates the filter against the type of sample, which must be a struct or a pointer to one, and
+// returns a reusable Predicate. The same errors as Filter are returned for invalid filters
+func Compile(filter squirrel.Sqlizer, sample interface{}) (*Predicate, error) {
+	return defaultFilterer.Compile(filter, sample)
+}
+
+// Compile validates the filter against the type of sample and returns a reusable Predicate. See the package
+// level Compile for details
+func (f *Filterer) Compile(filter squirrel.Sqlizer, sample interface{}) (*Predicate, error) {
+	if sample == nil {
+		return nil, errors.New("sample is nil")
+	}
+	t := reflect.TypeOf(sample)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New("sample type is not filter-able")
+	}
+	return f.compile(filter, t)
+}
+
+// compile returns a Predicate of the filter for the struct type t
+func (f *Filterer) compile(filter squirrel.Sqlizer, t reflect.Type) (*Predicate, error) {
+	if filter == nil {
+		return &Predicate{typ: t, matcher: matchAlways}, nil
+	}
+	fields, err := f.getFields(t)
+	if err != nil {
+		return nil, fmt.Errorf("unable to use input type: %w", err)
+	}
+	m, err := f.compileFilter(filter, fields, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to use filter: %w", err)
+	}
+	return &Predicate{typ: t, matcher: m}, nil
+}
+
+// Match reports whether v matches the predicate. v must be of the type the predicate was compiled for, or
+// a pointer to it; otherwise Match returns false. Match also returns false if a driver.Valuer field returns
+// an error
+func (p *Predicate) Match(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return false
+		}
+		val = val.Elem()
+	}
+	if val.Type() != p.typ {
+		return false
+	}
+	if !val.CanAddr() {
+		// fields with pointer receiver driver.Valuers can only be used if addressable
+		addressable := reflect.New(p.typ).Elem()
+		addressable.Set(val)
+		val = addressable
+	}
+	matches, err := p.matcher(val)
+	return err == nil && matches
+}
+
+// Apply filters the input slice, storing the matching elements in output. Input must be a slice of the type
+// the predicate was compiled for, and output must be a pointer to a slice of identical type
+func (p *Predicate) Apply(input, output interface{}) error {
+	inVal, outVal, err := getParamValues(input, output)
+	if err != nil {
+		return fmt.Errorf("failed to validate in/out params: %w", err)
+	}
+	if inVal.Type().Elem() != p.typ {
+		return fmt.Errorf("input slice type is not %v", p.typ)
+	}
+	result, err := p.apply(inVal)
+	if err != nil {
+		return err
+	}
+	outVal.Set(result)
+	return nil
+}
+
+// apply returns a new slice of the elements of inVal matching the predicate
+func (p *Predicate) apply(inVal reflect.Value) (reflect.Value, error) {
+	outVal := reflect.MakeSlice(inVal.Type(), 0, 0)
+	for i := 0; i < inVal.Len(); i++ {
+		val := inVal.Index(i)
+		matches, err := p.matcher(val)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("unable to apply filter: %w", err)
+		}
+		if matches {
+			outVal = reflect.Append(outVal, val)
+		}
+	}
+	return outVal, nil
+}
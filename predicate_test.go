@@ -0,0 +1,140 @@
+package sqlice_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+func TestPredicate_Match(t *testing.T) {
+	type FooBar struct {
+		A int
+		B string `db:"bar"`
+	}
+	pred, err := sqlice.Compile(squirrel.And{squirrel.Gt{"A": 1}, squirrel.ILike{"bar": "b%"}}, FooBar{})
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+
+	tests := map[string]struct {
+		value    interface{}
+		expected bool
+	}{
+		"match":             {FooBar{A: 2, B: "Bar"}, true},
+		"no match":          {FooBar{A: 1, B: "bar"}, false},
+		"pointer":           {&FooBar{A: 2, B: "bar"}, true},
+		"nil pointer":       {(*FooBar)(nil), false},
+		"nil":               {nil, false},
+		"different type":    {struct{ A int }{A: 2}, false},
+		"different pointer": {&struct{ A int }{A: 2}, false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if matches := pred.Match(test.value); matches != test.expected {
+				t.Errorf("Expected %v got %v", test.expected, matches)
+			}
+		})
+	}
+}
+
+func TestPredicate_Apply(t *testing.T) {
+	type FooBar struct {
+		A int
+		B string `db:"bar"`
+	}
+	input := []FooBar{{A: 1, B: "one"}, {A: 2, B: "two"}, {A: 3, B: "three"}}
+
+	tests := map[string]struct {
+		filter         squirrel.Sqlizer
+		expectedOutput []FooBar
+	}{
+		"nil filter": {nil, input},
+		"Like":       {squirrel.Like{"bar": "t%"}, []FooBar{input[1], input[2]}},
+		"Or":         {squirrel.Or{squirrel.Eq{"A": 1}, squirrel.NotLike{"bar": "%e%"}}, []FooBar{input[0], input[1]}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pred, err := sqlice.Compile(test.filter, &FooBar{})
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			// applying twice must give the same result
+			for i := 0; i < 2; i++ {
+				var output []FooBar
+				if err := pred.Apply(input, &output); err != nil {
+					t.Fatal("Expected no error, got:", err)
+				}
+				if !reflect.DeepEqual(output, test.expectedOutput) {
+					t.Errorf("Expected '%v' got '%v'", test.expectedOutput, output)
+				}
+			}
+		})
+	}
+}
+
+func TestPredicate_ErrorConditions(t *testing.T) {
+	type FooBar struct {
+		A int
+	}
+	compileTests := map[string]struct {
+		filter squirrel.Sqlizer
+		sample interface{}
+	}{
+		"nil sample":             {squirrel.Eq{"A": 1}, nil},
+		"sample not filter-able": {squirrel.Eq{"A": 1}, "not a struct"},
+		"field not in struct":    {squirrel.Eq{"B": 1}, FooBar{}},
+		"field wrong type":       {squirrel.Lt{"A": "1"}, FooBar{}},
+	}
+	for name, test := range compileTests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := sqlice.Compile(test.filter, test.sample); err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+
+	pred, err := sqlice.Compile(squirrel.Eq{"A": 1}, FooBar{})
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	applyTests := map[string]struct {
+		input, output interface{}
+	}{
+		"input not slice":            {FooBar{}, &[]FooBar{}},
+		"output not slice pointer":   {[]FooBar{}, []FooBar{}},
+		"input/output type mismatch": {[]FooBar{}, &[]struct{ A int }{}},
+		"input type not compiled":    {[]struct{ A int }{}, &[]struct{ A int }{}},
+	}
+	for name, test := range applyTests {
+		t.Run(name, func(t *testing.T) {
+			if err := pred.Apply(test.input, test.output); err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func ExampleCompile() {
+	type FooBar struct {
+		A int
+		B string `db:"bar"`
+	}
+	pred, err := sqlice.Compile(squirrel.Like{"bar": "a%"}, FooBar{})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(pred.Match(FooBar{A: 1, B: "abc"}), pred.Match(FooBar{A: 2, B: "cba"}))
+
+	var output []FooBar
+	if err := pred.Apply([]FooBar{{A: 1, B: "ab"}, {A: 2, B: "ba"}, {A: 3, B: "aa"}}, &output); err != nil {
+		panic(err)
+	}
+	fmt.Println(output)
+	// Output:
+	// true false
+	// [{1 ab} {3 aa}]
+}
@@ -0,0 +1,422 @@
+package sqlice
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// selectColumn is a parsed column of a select list
+type selectColumn struct {
+	// expr is nil for *
+	expr exprNode
+	// name is the column's alias, or the name derived from expr
+	name string
+}
+
+// reservedWords can't be used as column aliases without AS, since they start the clauses following a select list
+var reservedWords = map[string]bool{
+	"FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true, "OFFSET": true,
+	"JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true, "ON": true,
+	"UNION": true, "SET": true, "AS": true, "AND": true, "OR": true, "NOT": true,
+}
+
+// parseSelectList parses the comma separated columns of a select list, e.g. "id, name AS n, *"
+func parseSelectList(sql string, args []interface{}) ([]selectColumn, error) {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, args: args}
+	columns, err := p.parseSelectColumns()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+func (p *parser) parseSelectColumns() ([]selectColumn, error) {
+	var columns []selectColumn
+	for {
+		if p.acceptSymbol("*") {
+			columns = append(columns, selectColumn{name: "*"})
+		} else {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			column := selectColumn{expr: expr, name: columnName(expr)}
+			_, as := p.acceptKeyword("AS")
+			if t := p.peek(); t.kind == tokenQuotedIdent || (t.kind == tokenIdent && !reservedWords[strings.ToUpper(t.text)]) {
+				column.name = p.next().text
+			} else if as {
+				return nil, p.unexpected("alias")
+			}
+			columns = append(columns, column)
+		}
+		if !p.acceptSymbol(",") {
+			return columns, nil
+		}
+	}
+}
+
+// columnName returns the name of an unaliased column. Like in Postgres, columns are named by the last part of
+// the column they reference, function calls by the function's name, and other expressions are named "?column?"
+func columnName(expr exprNode) string {
+	switch expr := expr.(type) {
+	case columnNode:
+		return expr.name[strings.LastIndex(expr.name, ".")+1:]
+	case funcNode:
+		return strings.ToLower(expr.name)
+	default:
+		return "?column?"
+	}
+}
+
+var (
+	mapRowType  = reflect.TypeOf(map[string]interface{}{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// projectedColumn is a compiled column of a projection
+type projectedColumn struct {
+	name string
+	eval evaluator
+	// field is the input field the column references, if any
+	field *fieldInfo
+	// dest is the index of the output struct field. It is nil for map outputs
+	dest []int
+}
+
+// projection converts input rows into output rows holding the selected columns
+type projection struct {
+	outType reflect.Type
+	columns []projectedColumn
+}
+
+// Project filters the input slice, then stores the selected columns of the matching elements in output, like
+// sqlx's Select scanning into a narrower struct. Output must be a pointer to a slice of either
+// map[string]interface{}, keyed by the column names, or of a struct whose field names match the selected
+// columns. The columns may be expressions, with aliases given by AS, and "*" selects every column. Values are
+// converted to the output fields' types, and fields implementing sql.Scanner are scanned
+func Project(input, output interface{}, filter squirrel.Sqlizer, columns ...string) error {
+	return defaultFilterer.Project(input, output, filter, columns...)
+}
+
+// Project filters the input slice and stores the selected columns of the results in output. See the package
+// level Project for details
+func (f *Filterer) Project(input, output interface{}, filter squirrel.Sqlizer, columns ...string) error {
+	inVal, outVal, err := getProjectionValues(input, output)
+	if err != nil {
+		return fmt.Errorf("failed to validate in/out params: %w", err)
+	}
+	if len(columns) == 0 {
+		return errors.New("no columns selected")
+	}
+	selectList, err := parseSelectList(strings.Join(columns, ", "), nil)
+	if err != nil {
+		return fmt.Errorf("unable to parse columns: %w", err)
+	}
+	result, err := f.filter(inVal, filter)
+	if err != nil {
+		return err
+	}
+	proj, err := f.compileProjection(selectList, inVal.Type().Elem(), outVal.Type().Elem())
+	if err != nil {
+		return fmt.Errorf("unable to use columns: %w", err)
+	}
+	result, err = proj.apply(result)
+	if err != nil {
+		return err
+	}
+	outVal.Set(result)
+	return nil
+}
+
+// getProjectionValues validates the params like getParamValues, but allows output to be a pointer to a slice of
+// map[string]interface{} or of any struct type
+func getProjectionValues(input, output interface{}) (reflect.Value, reflect.Value, error) {
+	inputValue, err := getInputValue(input)
+	if err != nil {
+		return reflect.Value{}, reflect.Value{}, err
+	}
+	outputValue, err := getOutputValue(output)
+	if err != nil {
+		return reflect.Value{}, reflect.Value{}, err
+	}
+	if t := outputValue.Type().Elem(); t != mapRowType && t.Kind() != reflect.Struct {
+		return reflect.Value{}, reflect.Value{}, errors.New("output slice type is not a struct or map[string]interface{}")
+	}
+	return inputValue, outputValue, nil
+}
+
+// isSelectAll reports whether the select list only selects *
+func isSelectAll(columns []selectColumn) bool {
+	for _, column := range columns {
+		if column.expr != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// compileProjection compiles the select list for converting rows of type inType into rows of type outType
+func (f *Filterer) compileProjection(columns []selectColumn, inType, outType reflect.Type) (*projection, error) {
+	inFields, err := f.getFields(inType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to use input type: %w", err)
+	}
+	var outFields map[string]fieldInfo
+	if outType != mapRowType {
+		if outFields, err = f.getFields(outType); err != nil {
+			return nil, fmt.Errorf("unable to use output type: %w", err)
+		}
+	}
+
+	proj := &projection{outType: outType}
+	for _, column := range columns {
+		if column.expr != nil {
+			projected, err := f.compileColumn(column.expr, column.name, inFields, outFields)
+			if err != nil {
+				return nil, err
+			}
+			proj.columns = append(proj.columns, projected)
+			continue
+		}
+		// * selects every input column, or into structs, every column with a matching output field
+		names := leafNames(inFields)
+		if outFields != nil {
+			names = leafNames(outFields)
+		}
+		for _, name := range names {
+			if _, ok := inFields[name]; !ok {
+				continue
+			}
+			projected, err := f.compileColumn(columnNode{name: name}, name, inFields, outFields)
+			if err != nil {
+				return nil, err
+			}
+			proj.columns = append(proj.columns, projected)
+		}
+	}
+	return proj, nil
+}
+
+func (f *Filterer) compileColumn(expr exprNode, name string, inFields, outFields map[string]fieldInfo) (projectedColumn, error) {
+	eval, err := f.compileNode(expr, inFields)
+	if err != nil {
+		return projectedColumn{}, err
+	}
+	projected := projectedColumn{name: name, eval: eval}
+	if column, ok := expr.(columnNode); ok {
+		field := inFields[f.normalizeName(column.name)]
+		projected.field = &field
+	}
+	if outFields != nil {
+		dest, ok := outFields[f.normalizeName(name)]
+		if !ok {
+			return projectedColumn{}, fmt.Errorf("output struct has no field named '%v'", name)
+		}
+		projected.dest = dest.Index
+	}
+	return projected, nil
+}
+
+// leafNames returns the sorted names of the fields that aren't nested structs with fields of their own
+func leafNames(fields map[string]fieldInfo) []string {
+	parents := make(map[string]bool)
+	for name := range fields {
+		for i := strings.LastIndex(name, "."); i >= 0; i = strings.LastIndex(name[:i], ".") {
+			parents[name[:i]] = true
+		}
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		if !parents[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// apply returns a new slice of the projected rows of inVal
+func (p *projection) apply(inVal reflect.Value) (reflect.Value, error) {
+	outVal := reflect.MakeSlice(reflect.SliceOf(p.outType), 0, inVal.Len())
+	for i := 0; i < inVal.Len(); i++ {
+		row, err := p.row(inVal.Index(i))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		outVal = reflect.Append(outVal, row)
+	}
+	return outVal, nil
+}
+
+// row returns the projection of item
+func (p *projection) row(item reflect.Value) (reflect.Value, error) {
+	if p.outType == mapRowType {
+		values, err := p.values(item)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		row := make(map[string]interface{}, len(p.columns))
+		for i, column := range p.columns {
+			row[column.name] = values[i]
+		}
+		return reflect.ValueOf(row), nil
+	}
+
+	row := reflect.New(p.outType).Elem()
+	for _, column := range p.columns {
+		dest := allocFieldByIndex(row, column.dest)
+		// copy fields of the same type as they are, so types without a sql.Scanner are kept
+		if column.field != nil && column.field.Type.AssignableTo(dest.Type()) {
+			if value, ok := fieldByIndex(item, column.field.Index); ok {
+				dest.Set(value)
+				continue
+			}
+		}
+		value, err := column.eval(item)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("unable to get column '%v': %w", column.name, err)
+		}
+		if err := assignValue(dest, value); err != nil {
+			return reflect.Value{}, fmt.Errorf("unable to set column '%v': %w", column.name, err)
+		}
+	}
+	return row, nil
+}
+
+// values returns the values of the columns for item, in the order they were selected
+func (p *projection) values(item reflect.Value) ([]interface{}, error) {
+	values := make([]interface{}, len(p.columns))
+	for i, column := range p.columns {
+		value, err := column.eval(item)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get column '%v': %w", column.name, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// allocFieldByIndex returns the nested field of v, allocating any nil embedded pointers on the way
+func allocFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// assignValue sets dest to value, converting it to dest's type. nil values are SQL NULL
+func assignValue(dest reflect.Value, value interface{}) error {
+	if dest.CanAddr() && dest.Addr().Type().Implements(scannerType) {
+		return dest.Addr().Interface().(sql.Scanner).Scan(value)
+	}
+	if value == nil {
+		switch dest.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			dest.Set(reflect.Zero(dest.Type()))
+			return nil
+		}
+		return fmt.Errorf("cannot assign NULL to %v", dest.Type())
+	}
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(dest.Type()):
+		dest.Set(v)
+	case dest.Kind() == reflect.Ptr:
+		elem := reflect.New(dest.Type().Elem())
+		if err := assignValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		dest.Set(elem)
+	case convertible(v.Type(), dest.Type()):
+		if isNumberKind(v.Kind()) {
+			if err := checkNumberRange(v, dest.Type()); err != nil {
+				return fmt.Errorf("cannot assign %v to %v: %w", value, dest.Type(), err)
+			}
+		}
+		dest.Set(v.Convert(dest.Type()))
+	default:
+		return fmt.Errorf("cannot assign %v to %v", v.Type(), dest.Type())
+	}
+	return nil
+}
+
+// checkNumberRange returns an error if converting the number v to type to would change its value, like
+// database/sql does when scanning. Integers converted to floats may still lose precision
+func checkNumberRange(v reflect.Value, to reflect.Type) error {
+	outOfRange := errors.New("value out of range")
+	switch reducedKind(to.Kind()) {
+	case reflect.Int64:
+		switch reducedKind(v.Kind()) {
+		case reflect.Int64:
+			if reflect.Zero(to).OverflowInt(v.Int()) {
+				return outOfRange
+			}
+		case reflect.Uint64:
+			if v.Uint() > math.MaxInt64 || reflect.Zero(to).OverflowInt(int64(v.Uint())) {
+				return outOfRange
+			}
+		case reflect.Float64:
+			f := v.Float()
+			if f != math.Trunc(f) {
+				return errors.New("value is not an integer")
+			}
+			if f < math.MinInt64 || f >= math.MaxInt64 || reflect.Zero(to).OverflowInt(int64(f)) {
+				return outOfRange
+			}
+		}
+	case reflect.Uint64:
+		switch reducedKind(v.Kind()) {
+		case reflect.Int64:
+			if v.Int() < 0 || reflect.Zero(to).OverflowUint(uint64(v.Int())) {
+				return outOfRange
+			}
+		case reflect.Uint64:
+			if reflect.Zero(to).OverflowUint(v.Uint()) {
+				return outOfRange
+			}
+		case reflect.Float64:
+			f := v.Float()
+			if f != math.Trunc(f) {
+				return errors.New("value is not an integer")
+			}
+			if f < 0 || f >= math.MaxUint64 || reflect.Zero(to).OverflowUint(uint64(f)) {
+				return outOfRange
+			}
+		}
+	case reflect.Float64:
+		if reducedKind(v.Kind()) == reflect.Float64 && reflect.Zero(to).OverflowFloat(v.Float()) {
+			return outOfRange
+		}
+	}
+	return nil
+}
+
+// convertible reports whether values of type from can be converted to type to without changing their meaning,
+// unlike e.g. converting an int to a string
+func convertible(from, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	fromKind, toKind := reducedKind(from.Kind()), reducedKind(to.Kind())
+	return fromKind == toKind || (isNumberKind(fromKind) && isNumberKind(toKind))
+}
@@ -0,0 +1,228 @@
+package sqlice_test
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+func TestProject_Maps(t *testing.T) {
+	tests := map[string]struct {
+		filter         squirrel.Sqlizer
+		columns        []string
+		expectedOutput []map[string]interface{}
+	}{
+		"columns": {
+			filter:  squirrel.Lt{"id": 3},
+			columns: []string{"id", "name"},
+			expectedOutput: []map[string]interface{}{
+				{"id": 1, "name": "alice"},
+				{"id": 2, "name": "bob"},
+			},
+		},
+		"filtered": {
+			filter:         squirrel.Eq{"name": "bob"},
+			columns:        []string{"id"},
+			expectedOutput: []map[string]interface{}{{"id": 2}, {"id": 5}},
+		},
+		"nulls": {
+			filter:  squirrel.Lt{"id": 3},
+			columns: []string{"nickname, discount"},
+			expectedOutput: []map[string]interface{}{
+				{"nickname": "al", "discount": 5},
+				{"nickname": nil, "discount": nil},
+			},
+		},
+		"aliases and expressions": {
+			filter:  squirrel.Lt{"id": 3},
+			columns: []string{"name AS n", "addr.city", "id > 1 is_new", "discount IS NULL"},
+			expectedOutput: []map[string]interface{}{
+				{"n": "alice", "city": "Springfield", "is_new": false, "?column?": false},
+				{"n": "bob", "city": "", "is_new": true, "?column?": true},
+			},
+		},
+		"star": {
+			filter:  squirrel.Eq{"id": 2},
+			columns: []string{"*"},
+			expectedOutput: []map[string]interface{}{
+				{"id": 2, "name": "bob", "nickname": nil, "status": "pending", "amount": float64(20), "discount": nil, "login_count": uint(3),
+					"small": int8(0), "active": true, "created_at": records()[1].CreatedAt, "addr.city": "", "addr.zip": ""},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []map[string]interface{}
+			err := sqlice.Project(records(), &output, test.filter, test.columns...)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if !reflect.DeepEqual(output, test.expectedOutput) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestProject_Structs(t *testing.T) {
+	type summary struct {
+		ID   int64
+		Name string
+	}
+	type nullable struct {
+		ID       int
+		Nickname *string
+		Discount sql.NullInt64
+		City     string
+	}
+	type narrow struct {
+		ID    int8
+		Score int
+	}
+	type star struct {
+		ID      int
+		Name    string
+		Address Address `db:"addr"`
+		Unused  bool
+	}
+	nickname := "al"
+
+	tests := map[string]struct {
+		columns        []string
+		output         interface{}
+		expectedOutput interface{}
+	}{
+		"subset": {
+			columns:        []string{"id", "name"},
+			output:         &[]summary{},
+			expectedOutput: &[]summary{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}},
+		},
+		"conversions": {
+			columns: []string{"id", "nickname", "discount", "addr.city AS city"},
+			output:  &[]nullable{},
+			expectedOutput: &[]nullable{
+				{ID: 1, Nickname: &nickname, Discount: sql.NullInt64{Int64: 5, Valid: true}, City: "Springfield"},
+				{ID: 2},
+			},
+		},
+		"narrowing": {
+			columns:        []string{"id", "id * 2.0 AS score"},
+			output:         &[]narrow{},
+			expectedOutput: &[]narrow{{ID: 1, Score: 2}, {ID: 2, Score: 4}},
+		},
+		"star": {
+			columns: []string{"*"},
+			output:  &[]star{},
+			expectedOutput: &[]star{
+				{ID: 1, Name: "alice", Address: Address{City: "Springfield"}},
+				{ID: 2, Name: "bob"},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := sqlice.Project(records(), test.output, squirrel.Lt{"id": 3}, test.columns...)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if !reflect.DeepEqual(test.output, test.expectedOutput) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedOutput, test.output)
+			}
+		})
+	}
+}
+
+func TestProject_ErrorConditions(t *testing.T) {
+	type noName struct {
+		ID int
+	}
+	type wrongType struct {
+		Name int
+	}
+	type notNullable struct {
+		Discount int
+	}
+	type small struct {
+		ID int8
+	}
+	type unsigned struct {
+		ID uint
+	}
+	tests := map[string]struct {
+		output  interface{}
+		columns []string
+	}{
+		"no columns":          {output: &[]map[string]interface{}{}, columns: nil},
+		"bad output":          {output: &[]int{}, columns: []string{"id"}},
+		"output not pointer":  {output: []map[string]interface{}{}, columns: []string{"id"}},
+		"unknown column":      {output: &[]map[string]interface{}{}, columns: []string{"foo"}},
+		"invalid column":      {output: &[]map[string]interface{}{}, columns: []string{"id AS"}},
+		"missing destination": {output: &[]noName{}, columns: []string{"id", "name"}},
+		"wrong type":          {output: &[]wrongType{}, columns: []string{"name"}},
+		"null into value":     {output: &[]notNullable{}, columns: []string{"discount"}},
+		"integer overflow":    {output: &[]small{}, columns: []string{"id * 300 AS id"}},
+		"negative unsigned":   {output: &[]unsigned{}, columns: []string{"-id AS id"}},
+		"fractional float":    {output: &[]noName{}, columns: []string{"id * 2.75 AS id"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := sqlice.Project(records(), test.output, nil, test.columns...)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestSelect_Projection(t *testing.T) {
+	type summary struct {
+		ID   int
+		Name string `db:"full_name"`
+	}
+	var output []summary
+	query := squirrel.Select("id", "name AS full_name").From("users").Where("id > ?", 3)
+	err := sqlice.Select(records(), &output, query)
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expectedOutput := []summary{{ID: 4, Name: "carol"}, {ID: 5, Name: "bob"}}
+	if !reflect.DeepEqual(output, expectedOutput) {
+		t.Errorf("Expected '%v' got '%v'", expectedOutput, output)
+	}
+
+	// selecting columns into the input type leaves the other fields zero
+	var same []Record
+	err = sqlice.Select(records(), &same, squirrel.Select("name").From("users").Where("id < ?", 3))
+	if err != nil {
+		t.Fatal("Expected no error, got:", err)
+	}
+	expectedSame := []Record{{Name: "alice"}, {Name: "bob"}}
+	if !reflect.DeepEqual(same, expectedSame) {
+		t.Errorf("Expected '%v' got '%v'", expectedSame, same)
+	}
+}
+
+func ExampleProject() {
+	type User struct {
+		ID       int
+		Name     string
+		Password string
+	}
+	type PublicUser struct {
+		ID   int
+		Name string
+	}
+	input := []User{{ID: 1, Name: "alice", Password: "hunter2"}, {ID: 2, Name: "bob", Password: "letmein"}}
+	var output []PublicUser
+
+	err := sqlice.Project(input, &output, squirrel.Gt{"id": 1}, "id", "name")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(output)
+	// Output: [{2 bob}]
+}
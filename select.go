@@ -0,0 +1,695 @@
+package sqlice
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lann/builder"
+)
+
+// selectPlan is a SELECT query parsed into the parts sqlice evaluates
+type selectPlan struct {
+	// columns is empty if the query selects every column
+	columns []selectColumn
+	groupBy []exprNode
+	// having is nil if the query has no HAVING clause
+	having exprNode
+	// where is nil if the query has no WHERE clause
+	where   exprNode
+	orderBy []orderNode
+	// limit is nil if the query has no LIMIT clause
+	limit  *uint64
+	offset uint64
+}
+
+// Select runs the query against the input slice, storing the resulting rows in output. The WHERE, GROUP BY,
+// HAVING, ORDER BY, LIMIT and OFFSET clauses are applied in that order. Where and having parts are evaluated like
+// Expr filters, and the values of filters such as squirrel.Eq are validated against the fields like Filter
+// validates them. ValueFilterers are only supported by Filter. The columns may use aggregates like Aggregate, and
+// HAVING and ORDER BY may also refer to the selected columns by name. Output must be a pointer to a slice of the
+// input's type, or of another struct type or map[string]interface{} to store the selected columns like Project.
+// Queries using clauses sqlice can't evaluate, such as joins or selecting from a subquery, return an error
+func Select(input, output interface{}, query squirrel.SelectBuilder) error {
+	return defaultFilterer.Select(input, output, query)
+}
+
+// Select runs the query against the input slice. See the package level Select for details
+func (f *Filterer) Select(input, output interface{}, query squirrel.SelectBuilder) error {
+	inVal, outVal, err := getProjectionValues(input, output)
+	if err != nil {
+		return fmt.Errorf("failed to validate in/out params: %w", err)
+	}
+	_, plan, err := planSelect(query)
+	if err != nil {
+		return fmt.Errorf("unable to use query: %w", err)
+	}
+	result, err := f.execSelect(inVal, plan, outVal.Type().Elem())
+	if err != nil {
+		return err
+	}
+	outVal.Set(result)
+	return nil
+}
+
+// planSelect extracts the parts of the query from the builder's data, removing the table's name and alias from
+// its columns, and returns the table it selects from, or "" if it has no FROM clause
+func planSelect(query squirrel.SelectBuilder) (string, *selectPlan, error) {
+	for _, clause := range []string{"Prefixes", "Options", "Joins", "Suffixes"} {
+		if value, ok := builder.Get(query, clause); ok && reflect.ValueOf(value).Len() > 0 {
+			return "", nil, fmt.Errorf("unsupported clause %v", clause)
+		}
+	}
+	table, alias, err := fromTable(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	plan := &selectPlan{}
+	columnParts, _ := builder.Get(query, "Columns")
+	for _, part := range sqlizers(columnParts) {
+		sql, args, err := part.ToSql()
+		if err != nil {
+			return "", nil, err
+		}
+		columns, err := parseSelectList(sql, args)
+		if err != nil {
+			return "", nil, fmt.Errorf("unable to parse column '%v': %w", sql, err)
+		}
+		plan.columns = append(plan.columns, columns...)
+	}
+
+	whereParts, _ := builder.Get(query, "WhereParts")
+	plan.where, err = parseConditions(sqlizers(whereParts))
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to parse where part: %w", err)
+	}
+
+	groupBys, _ := builder.Get(query, "GroupBys")
+	if groupBys, _ := groupBys.([]string); len(groupBys) > 0 {
+		nodes, err := parseExpressionList(strings.Join(groupBys, ", "))
+		if err != nil {
+			return "", nil, fmt.Errorf("unable to parse group by: %w", err)
+		}
+		plan.groupBy = nodes
+	}
+
+	havingParts, _ := builder.Get(query, "HavingParts")
+	having, err := parseConditions(sqlizers(havingParts))
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to parse having part: %w", err)
+	}
+	plan.having = having
+
+	orderByParts, _ := builder.Get(query, "OrderByParts")
+	for _, part := range sqlizers(orderByParts) {
+		sql, args, err := part.ToSql()
+		if err != nil {
+			return "", nil, err
+		}
+		terms, err := parseOrderBy(sql, args)
+		if err != nil {
+			return "", nil, fmt.Errorf("unable to parse order by '%v': %w", sql, err)
+		}
+		plan.orderBy = append(plan.orderBy, terms...)
+	}
+
+	if limit, ok := builder.Get(query, "Limit"); ok && limit.(string) != "" {
+		n, err := strconv.ParseUint(limit.(string), 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid limit '%v'", limit)
+		}
+		plan.limit = &n
+	}
+	if offset, ok := builder.Get(query, "Offset"); ok && offset.(string) != "" {
+		n, err := strconv.ParseUint(offset.(string), 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid offset '%v'", offset)
+		}
+		plan.offset = n
+	}
+	plan.unqualify(table, alias)
+	return table, plan, nil
+}
+
+// fromTable returns the table name and alias of the query's FROM clause, or "" if it has none. Only plain table
+// names can be selected from
+func fromTable(query squirrel.SelectBuilder) (string, string, error) {
+	from, ok := builder.Get(query, "From")
+	if !ok || from == nil {
+		return "", "", nil
+	}
+	sql, args, err := from.(squirrel.Sqlizer).ToSql()
+	if err != nil {
+		return "", "", err
+	}
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to parse FROM clause '%v': %w", sql, err)
+	}
+	p := &parser{tokens: tokens, args: args}
+	table, alias, err := p.parseTableName()
+	if err != nil {
+		return "", "", fmt.Errorf("unable to parse FROM clause '%v': %w", sql, err)
+	}
+	if err := p.expectEnd(); err != nil {
+		return "", "", fmt.Errorf("unable to parse FROM clause '%v': %w", sql, err)
+	}
+	return table, alias, nil
+}
+
+// parseSelect parses a SELECT statement following the SELECT keyword, returning the table it selects from
+func (p *parser) parseSelect() (string, *selectPlan, error) {
+	plan := &selectPlan{}
+	columns, err := p.parseSelectColumns()
+	if err != nil {
+		return "", nil, err
+	}
+	if !isSelectAll(columns) {
+		plan.columns = columns
+	}
+	if err := p.expectKeyword("FROM"); err != nil {
+		return "", nil, err
+	}
+	table, alias, err := p.parseTableName()
+	if err != nil {
+		return "", nil, err
+	}
+	if _, ok := p.acceptKeyword("WHERE"); ok {
+		if plan.where, err = p.parseExpr(); err != nil {
+			return "", nil, err
+		}
+	}
+	if _, ok := p.acceptKeyword("GROUP"); ok {
+		if err := p.expectKeyword("BY"); err != nil {
+			return "", nil, err
+		}
+		if plan.groupBy, err = p.parseExpressions(); err != nil {
+			return "", nil, err
+		}
+	}
+	if _, ok := p.acceptKeyword("HAVING"); ok {
+		if plan.having, err = p.parseExpr(); err != nil {
+			return "", nil, err
+		}
+	}
+	if _, ok := p.acceptKeyword("ORDER"); ok {
+		if err := p.expectKeyword("BY"); err != nil {
+			return "", nil, err
+		}
+		if plan.orderBy, err = p.parseOrderTerms(); err != nil {
+			return "", nil, err
+		}
+	}
+	if _, ok := p.acceptKeyword("LIMIT"); ok {
+		n, err := p.parseCount()
+		if err != nil {
+			return "", nil, err
+		}
+		plan.limit = &n
+	}
+	if _, ok := p.acceptKeyword("OFFSET"); ok {
+		if plan.offset, err = p.parseCount(); err != nil {
+			return "", nil, err
+		}
+	}
+	plan.unqualify(table, alias)
+	return table, plan, nil
+}
+
+// parseTableName parses the table in a FROM clause, returning its name and its alias, if it has one
+func (p *parser) parseTableName() (string, string, error) {
+	t := p.next()
+	if t.kind != tokenQuotedIdent && (t.kind != tokenIdent || reservedWords[strings.ToUpper(t.text)]) {
+		p.backup(t)
+		return "", "", p.unexpected("table name")
+	}
+	_, as := p.acceptKeyword("AS")
+	if next := p.peek(); next.kind == tokenQuotedIdent || (next.kind == tokenIdent && !reservedWords[strings.ToUpper(next.text)]) {
+		return t.text, p.next().text, nil
+	} else if as {
+		return "", "", p.unexpected("alias")
+	}
+	return t.text, "", nil
+}
+
+// unqualify removes the qualifiers from the columns of the plan, so columns can be referenced as e.g.
+// "users.id" or "u.id". Columns without one of the qualifiers are left as they are
+func (plan *selectPlan) unqualify(qualifiers ...string) {
+	for i, column := range plan.columns {
+		if column.expr != nil {
+			plan.columns[i].expr = unqualifyColumns(column.expr, qualifiers)
+		}
+	}
+	for i, node := range plan.groupBy {
+		plan.groupBy[i] = unqualifyColumns(node, qualifiers)
+	}
+	for i, term := range plan.orderBy {
+		plan.orderBy[i].expr = unqualifyColumns(term.expr, qualifiers)
+	}
+	if plan.where != nil {
+		plan.where = unqualifyColumns(plan.where, qualifiers)
+	}
+	if plan.having != nil {
+		plan.having = unqualifyColumns(plan.having, qualifiers)
+	}
+}
+
+// unqualifyColumns returns a copy of node with the qualifiers removed from its columns
+func unqualifyColumns(node exprNode, qualifiers []string) exprNode {
+	unqualify := func(name string) string {
+		for _, qualifier := range qualifiers {
+			prefix := strings.ToLower(qualifier) + "."
+			if qualifier != "" && strings.HasPrefix(strings.ToLower(name), prefix) {
+				return name[len(prefix):]
+			}
+		}
+		return name
+	}
+	node, _ = rewriteNode(node, func(n exprNode) (exprNode, bool, error) {
+		switch n := n.(type) {
+		case columnNode:
+			return columnNode{name: unqualify(n.name)}, true, nil
+		case filterNode:
+			checks := make([]valueCheck, len(n.checks))
+			for i, check := range n.checks {
+				check.column = unqualify(check.column)
+				checks[i] = check
+			}
+			return filterNode{checks: checks, expr: unqualifyColumns(n.expr, qualifiers)}, true, nil
+		default:
+			return nil, false, nil
+		}
+	})
+	return node
+}
+
+// parseCount parses the non-negative integer of a LIMIT or OFFSET clause, which may be a placeholder
+func (p *parser) parseCount() (uint64, error) {
+	t := p.peek()
+	node, err := p.parseOperand()
+	if err != nil {
+		return 0, err
+	}
+	if value, ok := node.(valueNode); ok && value.value != nil {
+		v := reflect.ValueOf(value.value)
+		switch reducedKind(v.Kind()) {
+		case reflect.Int64:
+			if v.Int() >= 0 {
+				return uint64(v.Int()), nil
+			}
+		case reflect.Uint64:
+			return v.Uint(), nil
+		}
+	}
+	return 0, fmt.Errorf("expected a non-negative integer at position %d, got '%v'", t.pos, t.text)
+}
+
+// parseConditions parses the parts of a WHERE or HAVING clause, joining them with AND. It returns nil if there
+// are no parts. The parts are rendered and parsed, and those built from filters Filter validates, like
+// squirrel.Eq, are kept as filterNodes so their values are validated like Filter validates them
+func parseConditions(parts []squirrel.Sqlizer) (exprNode, error) {
+	var conditions exprNode
+	for _, part := range parts {
+		sql, args, err := part.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if sql == "" {
+			continue
+		}
+		node, err := parseExpression(sql, args)
+		if err != nil {
+			return nil, fmt.Errorf("'%v': %w", sql, err)
+		}
+		if pred, ok := wherePredicate(part); ok {
+			if checks, ok := filterChecks(pred); ok {
+				node = filterNode{checks: checks, expr: node}
+			}
+		}
+		if conditions == nil {
+			conditions = node
+		} else {
+			conditions = logicalNode{and: true, left: conditions, right: node}
+		}
+	}
+	return conditions, nil
+}
+
+// wherePredicate returns the predicate given to squirrel's Where or Having for the part, if it's one. The
+// predicate is held in an unexported field, so only its types, not its values, can be read from it
+func wherePredicate(part squirrel.Sqlizer) (reflect.Value, bool) {
+	partVal := reflect.ValueOf(part)
+	if partVal.Kind() != reflect.Ptr || partVal.IsNil() {
+		return reflect.Value{}, false
+	}
+	partType := partVal.Type().Elem()
+	if partType.Kind() != reflect.Struct || partType.PkgPath() != squirrelExprType.PkgPath() {
+		return reflect.Value{}, false
+	}
+	pred := partVal.Elem().FieldByName("pred")
+	if !pred.IsValid() || pred.Kind() != reflect.Interface || pred.IsNil() {
+		return reflect.Value{}, false
+	}
+	return pred.Elem(), true
+}
+
+// valueCheck is the type of a value of a filter like squirrel.Eq given to Where or Having, which is checked
+// against the field of its column like Filter checks the value
+type valueCheck struct {
+	column string
+	// valueType is nil if the value is NULL
+	valueType reflect.Type
+	// elemTypes are the types of the values of a list of candidate values (SQL IN)
+	elemTypes []reflect.Type
+	// equality is set for Eq and NotEq, and like for the Like filters
+	equality, like bool
+}
+
+// filterKind describes the filters Filter validates the values of
+type filterKind struct {
+	equality, like bool
+}
+
+var filterKinds = map[reflect.Type]filterKind{
+	reflect.TypeOf(map[string]interface{}{}): {equality: true},
+	reflect.TypeOf(squirrel.Eq{}):            {equality: true},
+	reflect.TypeOf(squirrel.NotEq{}):         {equality: true},
+	reflect.TypeOf(squirrel.Gt{}):            {},
+	reflect.TypeOf(squirrel.Lt{}):            {},
+	reflect.TypeOf(squirrel.GtOrEq{}):        {},
+	reflect.TypeOf(squirrel.LtOrEq{}):        {},
+	reflect.TypeOf(squirrel.Like{}):          {like: true},
+	reflect.TypeOf(squirrel.NotLike{}):       {like: true},
+	reflect.TypeOf(squirrel.ILike{}):         {like: true},
+	reflect.TypeOf(squirrel.NotILike{}):      {like: true},
+}
+
+var (
+	andType           = reflect.TypeOf(squirrel.And{})
+	orType            = reflect.TypeOf(squirrel.Or{})
+	selectBuilderType = reflect.TypeOf(squirrel.SelectBuilder{})
+)
+
+// filterChecks returns the checks of the values of the predicate, if it's a filter Filter validates or an And
+// or Or of them
+func filterChecks(pred reflect.Value) ([]valueCheck, bool) {
+	if pred.Type() == andType || pred.Type() == orType {
+		var checks []valueCheck
+		for i := 0; i < pred.Len(); i++ {
+			if part := pred.Index(i); !part.IsNil() {
+				partChecks, _ := filterChecks(part.Elem())
+				checks = append(checks, partChecks...)
+			}
+		}
+		return checks, true
+	}
+	kind, ok := filterKinds[pred.Type()]
+	if !ok {
+		return nil, false
+	}
+	keys := pred.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	checks := make([]valueCheck, len(keys))
+	for i, key := range keys {
+		checks[i] = valueCheck{column: key.String(), equality: kind.equality, like: kind.like}
+		value, ok := indirectType(pred.MapIndex(key))
+		if !ok {
+			continue
+		}
+		checks[i].valueType = value.Type()
+		if kind.equality && (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) && !isValuer(value.Type()) {
+			checks[i].elemTypes = make([]reflect.Type, value.Len())
+			for j := range checks[i].elemTypes {
+				if elem, ok := indirectType(value.Index(j)); ok {
+					checks[i].elemTypes[j] = elem.Type()
+				}
+			}
+		}
+	}
+	return checks, true
+}
+
+// indirectType dereferences the pointers and interfaces of v, stopping at driver.Valuers. If v is nil, ok is
+// false
+func indirectType(v reflect.Value) (value reflect.Value, ok bool) {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !isValuer(v.Type()) {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	return v, v.IsValid()
+}
+
+// checkValues validates the values of a filterNode against the fields like sanitizeMap and sanitizeStringMap
+// validate them. driver.Valuers and subqueries are only checked once they're evaluated
+func (f *Filterer) checkValues(checks []valueCheck, fields map[string]fieldInfo) error {
+	for _, check := range checks {
+		field, ok := fields[f.normalizeName(check.column)]
+		if !ok {
+			return fmt.Errorf("struct has no field named '%v'", check.column)
+		}
+		switch {
+		case check.like:
+			if check.valueType == nil || check.valueType.Kind() != reflect.String {
+				return errors.New("expression must be a string")
+			}
+		case check.valueType == nil:
+			if !check.equality {
+				return fmt.Errorf("cannot compare field '%v' to NULL", check.column)
+			}
+			if !field.Optional && !nullable(field.Type) {
+				return fmt.Errorf("field '%v' of type %v can not be NULL", check.column, field.Type)
+			}
+		case isValuer(check.valueType) || check.valueType == selectBuilderType:
+		case typesMatch(field.Type, check.valueType):
+		case check.elemTypes != nil:
+			for _, elemType := range check.elemTypes {
+				if elemType != nil && !isValuer(elemType) && !typesMatch(field.Type, elemType) {
+					return fmt.Errorf("expected values of field '%v' to have type %v, got %v", check.column, field.Type, elemType)
+				}
+			}
+		default:
+			return fmt.Errorf("expected field '%v' to have type %v, got %v", check.column, field.Type, check.valueType)
+		}
+	}
+	return nil
+}
+
+// sqlizers converts builder data holding a []squirrel.Sqlizer. Unset data is nil
+func sqlizers(data interface{}) []squirrel.Sqlizer {
+	parts, _ := data.([]squirrel.Sqlizer)
+	return parts
+}
+
+// execSelect returns a new slice of the rows of inVal selected by the plan, converted to rows of type outType
+func (f *Filterer) execSelect(inVal reflect.Value, plan *selectPlan, outType reflect.Type) (reflect.Value, error) {
+	query, err := f.compileSelect(inVal.Type().Elem(), plan, outType)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	result, err := query.rows(inVal)
+	if err != nil || query.proj == nil {
+		return result, err
+	}
+	return query.proj.apply(result)
+}
+
+// compiledSelect is a select plan compiled for an input type
+type compiledSelect struct {
+	// where and having are nil if the query has no such clause
+	where  *Predicate
+	group  *grouping
+	having *Predicate
+	terms  []orderTerm
+	offset uint64
+	limit  *uint64
+	// proj is nil if the rows aren't projected
+	proj *projection
+}
+
+// compileSelect compiles the plan for selecting rows of type inType and converting them to rows of type outType
+func (f *Filterer) compileSelect(inType reflect.Type, plan *selectPlan, outType reflect.Type) (*compiledSelect, error) {
+	fields, err := f.getFields(inType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to use input type: %w", err)
+	}
+
+	query := &compiledSelect{offset: plan.offset, limit: plan.limit}
+	if plan.where != nil {
+		eval, err := f.compileNode(plan.where, fields)
+		if err != nil {
+			return nil, fmt.Errorf("unable to use query: %w", err)
+		}
+		query.where = &Predicate{typ: inType, matcher: evaluatorMatcher(eval)}
+	}
+
+	// grouped queries evaluate the rest of the query against the grouped rows
+	rowType := inType
+	plan = f.resolveAliases(plan, fields)
+	if plan.isGrouped() {
+		if query.group, plan, err = f.compileGrouping(fields, plan); err != nil {
+			return nil, fmt.Errorf("unable to use query: %w", err)
+		}
+		rowType = query.group.rowType
+		if fields, err = f.getFields(rowType); err != nil {
+			return nil, fmt.Errorf("unable to use query: %w", err)
+		}
+		fields = f.withColumnNames(fields, plan.columns)
+		if plan.having != nil {
+			eval, err := f.compileNode(plan.having, fields)
+			if err != nil {
+				return nil, fmt.Errorf("unable to use query: %w", err)
+			}
+			query.having = &Predicate{typ: rowType, matcher: evaluatorMatcher(eval)}
+		}
+	}
+	columns, orderBy := plan.columns, plan.orderBy
+
+	if len(orderBy) > 0 {
+		if query.terms, err = f.compileOrderBy(orderBy, fields); err != nil {
+			return nil, fmt.Errorf("unable to use query: %w", err)
+		}
+	}
+
+	// rows are only projected if the output needs it
+	if outType != rowType || !isSelectAll(columns) {
+		if len(columns) == 0 {
+			columns = []selectColumn{{name: "*"}}
+		}
+		if query.proj, err = f.compileProjection(columns, rowType, outType); err != nil {
+			return nil, fmt.Errorf("unable to use query: %w", err)
+		}
+	}
+	return query, nil
+}
+
+// withColumnNames returns the fields with the names of the selected columns added for the columns that are
+// fields, so filters can reference columns like COUNT(*) by name, like expressions do
+func (f *Filterer) withColumnNames(fields map[string]fieldInfo, columns []selectColumn) map[string]fieldInfo {
+	named := make(map[string]fieldInfo, len(fields)+len(columns))
+	for name, field := range fields {
+		named[name] = field
+	}
+	for _, column := range columns {
+		ref, ok := column.expr.(columnNode)
+		if !ok {
+			continue
+		}
+		name := f.normalizeName(column.name)
+		if _, exists := named[name]; exists {
+			continue
+		}
+		if field, ok := fields[f.normalizeName(ref.name)]; ok {
+			named[name] = field
+		}
+	}
+	return named
+}
+
+// rows returns a new slice of the rows of inVal selected by the query, before they are projected
+func (query *compiledSelect) rows(inVal reflect.Value) (reflect.Value, error) {
+	var err error
+	result := inVal
+	if query.where != nil {
+		if result, err = query.where.apply(result); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	if query.group != nil {
+		if result, err = query.group.apply(result); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	if query.having != nil {
+		if result, err = query.having.apply(result); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	if len(query.terms) > 0 {
+		if result, err = sortSlice(result, query.terms); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return limitSlice(result, query.offset, query.limit), nil
+}
+
+// isGrouped reports whether the query groups its rows, either by GROUP BY or by using aggregates
+func (plan *selectPlan) isGrouped() bool {
+	if len(plan.groupBy) > 0 || plan.having != nil {
+		return true
+	}
+	for _, column := range plan.columns {
+		if column.expr != nil && containsAggregate(column.expr) {
+			return true
+		}
+	}
+	for _, term := range plan.orderBy {
+		if containsAggregate(term.expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveAliases returns a copy of the plan with the columns of its ORDER BY and HAVING clauses that name an
+// output column, rather than a field, replaced by the output column's expression. This allows e.g. filtering
+// groups with squirrel.Gt{"count": 5}
+func (f *Filterer) resolveAliases(plan *selectPlan, fields map[string]fieldInfo) *selectPlan {
+	resolve := func(node exprNode) exprNode {
+		node, _ = rewriteNode(node, func(n exprNode) (exprNode, bool, error) {
+			column, ok := n.(columnNode)
+			if !ok {
+				return nil, false, nil
+			}
+			name := f.normalizeName(column.name)
+			if _, ok := fields[name]; ok {
+				return n, true, nil
+			}
+			for _, selected := range plan.columns {
+				if selected.expr != nil && f.normalizeName(selected.name) == name {
+					return selected.expr, true, nil
+				}
+			}
+			return n, true, nil
+		})
+		return node
+	}
+
+	resolved := *plan
+	resolved.orderBy = make([]orderNode, len(plan.orderBy))
+	for i, term := range plan.orderBy {
+		term.expr = resolve(term.expr)
+		resolved.orderBy[i] = term
+	}
+	if plan.having != nil {
+		resolved.having = resolve(plan.having)
+	}
+	return &resolved
+}
+
+// limitSlice returns the elements of inVal after skipping offset of them, keeping at most limit if it's
+// not nil
+func limitSlice(inVal reflect.Value, offset uint64, limit *uint64) reflect.Value {
+	length := uint64(inVal.Len())
+	start := offset
+	if start > length {
+		start = length
+	}
+	end := length
+	if limit != nil && *limit < end-start {
+		end = start + *limit
+	}
+	if start == 0 && end == length {
+		return inVal
+	}
+	// copy so the output doesn't share the input's backing array
+	outVal := reflect.MakeSlice(inVal.Type(), int(end-start), int(end-start))
+	reflect.Copy(outVal, inVal.Slice(int(start), int(end)))
+	return outVal
+}
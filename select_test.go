@@ -0,0 +1,221 @@
+package sqlice_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+func TestSelect(t *testing.T) {
+	query := squirrel.Select("*").From("users")
+
+	tests := map[string]struct {
+		query       squirrel.SelectBuilder
+		expectedIDs []int
+	}{
+		"no clauses": {
+			query:       query,
+			expectedIDs: []int{1, 2, 3, 4, 5},
+		},
+		"where": {
+			query:       query.Where(squirrel.Eq{"name": "alice"}).Where("id > ?", 2),
+			expectedIDs: []int{3},
+		},
+		"where map": {
+			query:       query.Where(map[string]interface{}{"id": []int{1, 3}}),
+			expectedIDs: []int{1, 3},
+		},
+		"where filters": {
+			query:       query.Where(squirrel.Or{squirrel.Eq{"name": "bob"}, squirrel.Lt{"discount": 6}}),
+			expectedIDs: []int{1, 2, 5},
+		},
+		"where empty in": {
+			query:       query.Where(squirrel.Eq{"id": []int{}}),
+			expectedIDs: []int{},
+		},
+		"qualified columns": {
+			query:       query.Where(squirrel.Eq{"users.name": "alice"}).OrderBy("users.id DESC"),
+			expectedIDs: []int{3, 1},
+		},
+		"qualified alias columns": {
+			query:       squirrel.Select("*").From("users u").Where("u.id > ?", 2),
+			expectedIDs: []int{3, 4, 5},
+		},
+		"order by": {
+			query:       query.OrderBy("name"),
+			expectedIDs: []int{1, 3, 2, 5, 4},
+		},
+		"order by stable": {
+			query:       query.OrderBy("name DESC"),
+			expectedIDs: []int{4, 2, 5, 1, 3},
+		},
+		"order by multiple": {
+			query:       query.OrderBy("name ASC, id DESC"),
+			expectedIDs: []int{3, 1, 5, 2, 4},
+		},
+		"order by multiple parts": {
+			query:       query.OrderBy("name").OrderBy("id DESC"),
+			expectedIDs: []int{3, 1, 5, 2, 4},
+		},
+		"nulls last by default": {
+			query:       query.OrderBy("discount"),
+			expectedIDs: []int{1, 5, 3, 2, 4},
+		},
+		"nulls first when descending": {
+			query:       query.OrderBy("discount DESC"),
+			expectedIDs: []int{2, 4, 3, 1, 5},
+		},
+		"nulls first": {
+			query:       query.OrderBy("discount NULLS FIRST"),
+			expectedIDs: []int{2, 4, 1, 5, 3},
+		},
+		"order by clause args": {
+			query:       query.OrderByClause("id = ? DESC, id", 3),
+			expectedIDs: []int{3, 1, 2, 4, 5},
+		},
+		"limit": {
+			query:       query.OrderBy("id DESC").Limit(2),
+			expectedIDs: []int{5, 4},
+		},
+		"offset": {
+			query:       query.OrderBy("id").Offset(3),
+			expectedIDs: []int{4, 5},
+		},
+		"limit and offset": {
+			query:       query.Where("discount IS NOT NULL").OrderBy("discount").Limit(2).Offset(1),
+			expectedIDs: []int{5, 3},
+		},
+		"offset past end": {
+			query:       query.Offset(10),
+			expectedIDs: []int{},
+		},
+		"limit zero": {
+			query:       query.Limit(0),
+			expectedIDs: []int{},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []Record
+			err := sqlice.Select(records(), &output, test.query)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if ids := recordIDs(output); !reflect.DeepEqual(ids, test.expectedIDs) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestSelect_ErrorConditions(t *testing.T) {
+	query := squirrel.Select("*").From("users")
+	tests := map[string]struct {
+		input, output interface{}
+		query         squirrel.SelectBuilder
+	}{
+		"bad output": {
+			input:  []Record{},
+			output: []Record{},
+			query:  query,
+		},
+		"unknown where column": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.Where("foo = ?", 1),
+		},
+		"where type mismatch": {
+			input:  records(),
+			output: &[]Record{},
+			query:  query.Where(squirrel.Eq{"name": 5}),
+		},
+		"where compare type mismatch": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.Where(squirrel.Gt{"amount": 2}),
+		},
+		"where list type mismatch": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.Where(squirrel.Eq{"id": []interface{}{1, "2"}}),
+		},
+		"where null not nullable": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.Where(squirrel.Or{squirrel.Eq{"name": nil}, squirrel.Eq{"id": 1}}),
+		},
+		"where like non string": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.Where(squirrel.Like{"name": 1}),
+		},
+		"where value filter": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.Where(sqlice.ValueFilterFunc(func(interface{}) bool { return true })),
+		},
+		"unknown order by column": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.OrderBy("foo"),
+		},
+		"invalid order by": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.OrderBy("id NULLS"),
+		},
+		"from subquery": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  squirrel.Select("*").FromSelect(query, "t"),
+		},
+		"other table qualifier": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.Where("orders.id = ?", 1),
+		},
+		"join": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.Join("orders ON orders.user_id = users.id"),
+		},
+		"group by": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.GroupBy("name"),
+		},
+		"distinct": {
+			input:  []Record{},
+			output: &[]Record{},
+			query:  query.Distinct(),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := sqlice.Select(test.input, test.output, test.query)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func ExampleSelect() {
+	type FooBar struct {
+		Name string
+		Age  int
+	}
+	input := []FooBar{{Name: "alice", Age: 30}, {Name: "bob", Age: 17}, {Name: "carol", Age: 42}, {Name: "dave", Age: 25}}
+	var output []FooBar
+
+	query := squirrel.Select("*").From("people").Where(squirrel.GtOrEq{"age": 18}).OrderBy("age DESC").Limit(2)
+	err := sqlice.Select(input, &output, query)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(output)
+	// Output: [{carol 42} {alice 30}]
+}
@@ -1,17 +1,18 @@
 package sqlice
 
 import (
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 )
 
-const fieldNameTag = "db"
-
 type numericOperation int
 
 const (
@@ -19,6 +20,7 @@ const (
 	opGT
 	opLTOrEQ
 	opGTOrEQ
+	opEQ
 )
 
 // ValueFilterer is the interface that wraps the FilterValue method.
@@ -46,39 +48,61 @@ func (vff ValueFilterFunc) ToSql() (string, []interface{}, error) {
 // filterable elements (a struct) and output must be a pointer to a slice of identical type. If the filter
 // contains fields not present in the struct or values that aren't compatible with corresponding field, an
 // error is returned. If a filter is encountered that is not from the squirrel package, it is only used if
-// it implements ValueFilterer
+// it implements ValueFilterer.
+//
+// Filter uses the default Filterer, which names fields by their "db" tag or their lowercased name and matches
+// them case insensitively. Use NewFilterer to change this behavior
 func Filter(input, output interface{}, filter squirrel.Sqlizer) error {
+	return defaultFilterer.Filter(input, output, filter)
+}
+
+// Filter filters the input slice using the filter, storing the result in output. See the package level Filter
+// for details
+func (f *Filterer) Filter(input, output interface{}, filter squirrel.Sqlizer) error {
 	inVal, outVal, err := getParamValues(input, output)
 	if err != nil {
 		return fmt.Errorf("failed to validate in/out params: %w", err)
 	}
-	// short circuit nil filters
-	if filter == nil {
-		outVal.Set(inVal)
-		return nil
+	result, err := f.filter(inVal, filter)
+	if err != nil {
+		return err
 	}
+	outVal.Set(result)
+	return nil
+}
 
-	fields := getFields(inVal.Type().Elem())
-	filter, err = sanitizeFilter(filter, fields)
+// FilterSlice filters the input slice using the filter, returning the elements that match. The element type
+// of the slice must be a filterable struct. See Filter for details
+func FilterSlice[T any](input []T, filter squirrel.Sqlizer) ([]T, error) {
+	inVal := reflect.ValueOf(input)
+	if inVal.Type().Elem().Kind() != reflect.Struct {
+		return nil, errors.New("input slice type is not filter-able")
+	}
+	result, err := defaultFilterer.filter(inVal, filter)
 	if err != nil {
-		return fmt.Errorf("unable to use filter: %w", err)
+		return nil, err
 	}
+	return result.Interface().([]T), nil
+}
 
-	outVal.Set(reflect.MakeSlice(inVal.Type(), 0, 0))
-	for i := 0; i < inVal.Len(); i++ {
-		val := inVal.Index(i)
-		matches, err := matchesFilter(val, filter, fields)
-		if err != nil {
-			return fmt.Errorf("unable to apply filter: %w", err)
-		}
-		if matches {
-			outVal.Set(reflect.Append(outVal, val))
-		}
+// filter returns a new slice of the elements of inVal matching the filter. inVal must be a slice of structs
+func (f *Filterer) filter(inVal reflect.Value, filter squirrel.Sqlizer) (reflect.Value, error) {
+	// short circuit nil filters
+	if filter == nil {
+		return inVal, nil
 	}
-	return nil
+
+	pred, err := f.compile(filter, inVal.Type().Elem())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return pred.apply(inVal)
 }
 
 func compareValues(v1, v2 reflect.Value, op numericOperation) bool {
+	if reducedKind(v1.Kind()) != reducedKind(v2.Kind()) {
+		return false
+	}
 	switch reducedKind(v1.Kind()) {
 	case reflect.Int64:
 		return compareInt(v1, v2, op)
@@ -88,6 +112,11 @@ func compareValues(v1, v2 reflect.Value, op numericOperation) bool {
 		return compareString(v1, v2, op)
 	case reflect.Float64:
 		return compareFloat(v1, v2, op)
+	case reflect.Struct:
+		if v1.Type() != timeType || v2.Type() != timeType {
+			return false
+		}
+		return compareTime(v1, v2, op)
 	default:
 		return false
 	}
@@ -105,6 +134,8 @@ func compareInt(val1, val2 reflect.Value, op numericOperation) bool {
 		return v1 <= v2
 	case opGTOrEQ:
 		return v1 >= v2
+	case opEQ:
+		return v1 == v2
 	default:
 		return false
 	}
@@ -122,6 +153,8 @@ func compareUint(val1, val2 reflect.Value, op numericOperation) bool {
 		return v1 <= v2
 	case opGTOrEQ:
 		return v1 >= v2
+	case opEQ:
+		return v1 == v2
 	default:
 		return false
 	}
@@ -139,6 +172,8 @@ func compareString(val1, val2 reflect.Value, op numericOperation) bool {
 		return v1 <= v2
 	case opGTOrEQ:
 		return v1 >= v2
+	case opEQ:
+		return v1 == v2
 	default:
 		return false
 	}
@@ -156,216 +191,494 @@ func compareFloat(val1, val2 reflect.Value, op numericOperation) bool {
 		return v1 <= v2
 	case opGTOrEQ:
 		return v1 >= v2
+	case opEQ:
+		return v1 == v2
 	default:
 		return false
 	}
 }
 
-func matchesFilter(item reflect.Value, filter squirrel.Sqlizer, fields map[string]fieldInfo) (bool, error) {
+func compareTime(val1, val2 reflect.Value, op numericOperation) bool {
+	v1 := val1.Interface().(time.Time)
+	v2 := val2.Interface().(time.Time)
+	switch op {
+	case opLT:
+		return v1.Before(v2)
+	case opGT:
+		return v1.After(v2)
+	case opLTOrEQ:
+		return !v1.After(v2)
+	case opGTOrEQ:
+		return !v1.Before(v2)
+	case opEQ:
+		return v1.Equal(v2)
+	default:
+		return false
+	}
+}
+
+// matcher reports whether item, a struct, matches a compiled filter
+type matcher func(item reflect.Value) (bool, error)
+
+// condition is a field of a comparison filter paired with its sanitized value
+type condition struct {
+	field fieldInfo
+	value interface{}
+}
+
+// likeCondition is a field of a Like filter paired with the compiled expression
+type likeCondition struct {
+	field fieldInfo
+	re    *regexp.Regexp
+}
+
+// UnsupportedFilterError is returned by Filterers in strict mode when a filter contains a Sqlizer that sqlice
+// can't evaluate
+type UnsupportedFilterError struct {
+	// Type is the type of the unsupported Sqlizer
+	Type reflect.Type
+	// Path is the position of the Sqlizer in the filter tree, e.g. "And[1].Or[0]". It is empty if the
+	// Sqlizer is the filter itself
+	Path string
+}
+
+func (e *UnsupportedFilterError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("unsupported filter type %v", e.Type)
+	}
+	return fmt.Sprintf("unsupported filter type %v at %v", e.Type, e.Path)
+}
+
+// compileFilter converts the filter into a matcher for structs with the given fields. It will return an
+// error if there's a filtered field that is not present in the struct and if the field and filter types are
+// not compatible. path is the position of the filter in the filter tree, used to report unsupported filters
+func (f *Filterer) compileFilter(filter squirrel.Sqlizer, fields map[string]fieldInfo, path string) (matcher, error) {
 	switch filter := filter.(type) {
 	case squirrel.And:
-		for _, f := range filter {
-			matches, err := matchesFilter(item, f, fields)
-			if err != nil {
-				return false, err
-			}
-			if !matches {
-				return false, nil
-			}
-		}
-		return true, nil
+		matchers, err := f.compileCond(filter, fields, path, "And")
+		return matchAll(matchers), err
 	case squirrel.Or:
-		if len(filter) == 0 {
-			return true, nil
+		matchers, err := f.compileCond(filter, fields, path, "Or")
+		return matchAny(matchers), err
+	case squirrel.Eq:
+		conditions, err := f.sanitizeMap(filter, fields, true)
+		return matchEq(conditions, false), err
+	case squirrel.NotEq:
+		conditions, err := f.sanitizeMap(filter, fields, true)
+		return matchEq(conditions, true), err
+	case squirrel.Gt:
+		conditions, err := f.sanitizeMap(filter, fields, false)
+		return matchCompare(conditions, opGT), err
+	case squirrel.Lt:
+		conditions, err := f.sanitizeMap(filter, fields, false)
+		return matchCompare(conditions, opLT), err
+	case squirrel.GtOrEq:
+		conditions, err := f.sanitizeMap(filter, fields, false)
+		return matchCompare(conditions, opGTOrEQ), err
+	case squirrel.LtOrEq:
+		conditions, err := f.sanitizeMap(filter, fields, false)
+		return matchCompare(conditions, opLTOrEQ), err
+	case squirrel.Like:
+		conditions, err := f.sanitizeStringMap(filter, fields, false)
+		return matchLike(conditions, false), err
+	case squirrel.NotLike:
+		conditions, err := f.sanitizeStringMap(filter, fields, false)
+		return matchLike(conditions, true), err
+	case squirrel.ILike:
+		conditions, err := f.sanitizeStringMap(filter, fields, true)
+		return matchLike(conditions, false), err
+	case squirrel.NotILike:
+		conditions, err := f.sanitizeStringMap(filter, fields, true)
+		return matchLike(conditions, true), err
+	case Keyset:
+		sql, args, err := filter.ToSql()
+		if err != nil || sql == "" {
+			return matchAlways, err
 		}
-		for _, f := range filter {
-			matches, err := matchesFilter(item, f, fields)
+		return f.compileExpression(sql, args, fields)
+	case ValueFilterer:
+		return func(item reflect.Value) (bool, error) {
+			return filter.FilterValue(item.Interface()), nil
+		}, nil
+	default:
+		if reflect.TypeOf(filter) == squirrelExprType {
+			sql, args, err := filter.ToSql()
 			if err != nil {
-				return false, err
-			}
-			if matches {
-				return true, nil
+				return nil, err
 			}
+			return f.compileExpression(sql, args, fields)
 		}
-		return false, nil
-	case squirrel.Eq:
-		for name, value := range filter {
-			field := fields[name]
-			if !reflect.DeepEqual(item.Field(field.Index).Interface(), value) {
-				return false, nil
-			}
+		if f.strict && filter != nil {
+			return nil, &UnsupportedFilterError{Type: reflect.TypeOf(filter), Path: path}
 		}
-		return true, nil
-	case squirrel.NotEq:
-		for name, value := range filter {
-			field := fields[name]
-			if reflect.DeepEqual(item.Field(field.Index).Interface(), value) {
-				return false, nil
-			}
+		return matchAlways, nil
+	}
+}
+
+func (f *Filterer) compileCond(filters []squirrel.Sqlizer, fields map[string]fieldInfo, path, name string) ([]matcher, error) {
+	if path != "" {
+		path += "."
+	}
+	output := make([]matcher, 0, len(filters))
+	for i, filter := range filters {
+		m, err := f.compileFilter(filter, fields, fmt.Sprintf("%v%v[%d]", path, name, i))
+		if err != nil {
+			return nil, err
 		}
-		return true, nil
-	case squirrel.Gt:
-		for name, value := range filter {
-			field := fields[name]
-			if !compareValues(item.Field(field.Index), reflect.ValueOf(value), opGT) {
-				return false, nil
+		output = append(output, m)
+	}
+	return output, nil
+}
+
+func matchAlways(reflect.Value) (bool, error) {
+	return true, nil
+}
+
+func matchAll(matchers []matcher) matcher {
+	return func(item reflect.Value) (bool, error) {
+		for _, m := range matchers {
+			if matches, err := m(item); err != nil || !matches {
+				return false, err
 			}
 		}
 		return true, nil
-	case squirrel.Lt:
-		for name, value := range filter {
-			field := fields[name]
-			if !compareValues(item.Field(field.Index), reflect.ValueOf(value), opLT) {
-				return false, nil
+	}
+}
+
+func matchAny(matchers []matcher) matcher {
+	if len(matchers) == 0 {
+		return matchAlways
+	}
+	return func(item reflect.Value) (bool, error) {
+		for _, m := range matchers {
+			if matches, err := m(item); err != nil || matches {
+				return matches, err
 			}
 		}
-		return true, nil
-	case squirrel.GtOrEq:
-		for name, value := range filter {
-			field := fields[name]
-			if !compareValues(item.Field(field.Index), reflect.ValueOf(value), opGTOrEQ) {
-				return false, nil
+		return false, nil
+	}
+}
+
+func matchEq(conditions []condition, negate bool) matcher {
+	return func(item reflect.Value) (bool, error) {
+		for _, cond := range conditions {
+			fieldVal, ok, err := fieldValue(item, cond.field)
+			if err != nil || !matchesEq(fieldVal, ok, cond.value, negate) {
+				return false, err
 			}
 		}
 		return true, nil
-	case squirrel.LtOrEq:
-		for name, value := range filter {
-			field := fields[name]
-			if !compareValues(item.Field(field.Index), reflect.ValueOf(value), opLTOrEQ) {
-				return false, nil
+	}
+}
+
+func matchCompare(conditions []condition, op numericOperation) matcher {
+	values := make([]reflect.Value, len(conditions))
+	for i, cond := range conditions {
+		values[i] = reflect.ValueOf(cond.value)
+	}
+	return func(item reflect.Value) (bool, error) {
+		for i, cond := range conditions {
+			fieldVal, ok, err := fieldValue(item, cond.field)
+			if err != nil || !ok || !compareValues(fieldVal, values[i], op) {
+				return false, err
 			}
 		}
 		return true, nil
-	case squirrel.Like:
-		for name, value := range filter {
-			field := fields[name]
-			reString := expressionToRegexp(fmt.Sprint(value))
-			if matches, err := regexp.MatchString(reString, fmt.Sprint(item.Field(field.Index).Interface())); err != nil || !matches {
+	}
+}
+
+func matchLike(conditions []likeCondition, negate bool) matcher {
+	return func(item reflect.Value) (bool, error) {
+		for _, cond := range conditions {
+			fieldVal, ok, err := fieldValue(item, cond.field)
+			if err != nil || !ok || cond.re.MatchString(fmt.Sprint(fieldVal.Interface())) == negate {
 				return false, err
 			}
 		}
 		return true, nil
-	case squirrel.NotLike:
-		for name, value := range filter {
-			field := fields[name]
-			reString := expressionToRegexp(fmt.Sprint(value))
-			if matches, err := regexp.MatchString(reString, fmt.Sprint(item.Field(field.Index).Interface())); err != nil || matches {
-				return false, err
+	}
+}
+
+// sanitizeMap validates the values of a comparison filter against the struct fields. Pointer values are
+// dereferenced. If equality is set (Eq and NotEq), nil values are accepted for nullable fields and slice and
+// array values that don't match the field type are treated as a list of candidate values (SQL IN), which are
+// stored as a valueList. Subqueries are run and their values used as such a list
+func (f *Filterer) sanitizeMap(filters map[string]interface{}, fields map[string]fieldInfo, equality bool) ([]condition, error) {
+	output := make([]condition, 0, len(filters))
+	for _, name := range sortedKeys(filters) {
+		value := filters[name]
+		field, ok := fields[f.normalizeName(name)]
+		if !ok {
+			return nil, fmt.Errorf("struct has no field named '%v'", name)
+		}
+		value, err := sanitizeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get value for field '%v': %w", name, err)
+		}
+		if value == nil {
+			if !equality {
+				return nil, fmt.Errorf("cannot compare field '%v' to NULL", name)
 			}
+			if !field.Optional && !nullable(field.Type) {
+				return nil, fmt.Errorf("field '%v' of type %v can not be NULL", name, field.Type)
+			}
+			output = append(output, condition{field: field})
+			continue
 		}
-		return true, nil
-	case squirrel.ILike:
-		for name, value := range filter {
-			field := fields[name]
-			reString := `(?i)` + expressionToRegexp(fmt.Sprint(value))
-			if matches, err := regexp.MatchString(reString, fmt.Sprint(item.Field(field.Index).Interface())); err != nil || !matches {
-				return false, err
+		if query, ok := value.(squirrel.SelectBuilder); ok {
+			if !equality {
+				return nil, fmt.Errorf("cannot compare field '%v' to a subquery", name)
+			}
+			subquery, err := newSubquery(query)
+			if err != nil {
+				return nil, fmt.Errorf("unable to use subquery for field '%v': %w", name, err)
+			}
+			values, err := f.subqueryValues(subquery)
+			if err != nil {
+				return nil, fmt.Errorf("unable to get values for field '%v': %w", name, err)
 			}
+			value = values
 		}
-		return true, nil
-	case squirrel.NotILike:
-		for name, value := range filter {
-			field := fields[name]
-			reString := `(?i)` + expressionToRegexp(fmt.Sprint(value))
-			if matches, err := regexp.MatchString(reString, fmt.Sprint(item.Field(field.Index).Interface())); err != nil || matches {
-				return false, err
+		if typesMatch(field.Type, reflect.TypeOf(value)) {
+			output = append(output, condition{field: field, value: value})
+			continue
+		}
+		if !equality || !isListType(value) {
+			return nil, fmt.Errorf("expected field '%v' to have type %v, got %v", name, field.Type, reflect.TypeOf(value))
+		}
+		listVal := reflect.ValueOf(value)
+		if elemType := listVal.Type().Elem(); elemType.Kind() != reflect.Interface && !typesMatch(field.Type, elemType) {
+			return nil, fmt.Errorf("expected values of field '%v' to have type %v, got %v", name, field.Type, elemType)
+		}
+		list := make(valueList, 0, listVal.Len())
+		for i := 0; i < listVal.Len(); i++ {
+			elem, err := sanitizeValue(listVal.Index(i).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("unable to get value for field '%v': %w", name, err)
+			}
+			if elem != nil && !typesMatch(field.Type, reflect.TypeOf(elem)) {
+				return nil, fmt.Errorf("expected values of field '%v' to have type %v, got %v", name, field.Type, reflect.TypeOf(elem))
 			}
+			list = append(list, elem)
 		}
-		return true, nil
-	case ValueFilterer:
-		return filter.FilterValue(item.Interface()), nil
-	default:
-		return true, nil
+		output = append(output, condition{field: field, value: list})
 	}
+	return output, nil
 }
 
-// sanitizeFilter will convert the filter to lowercase values for field names. It will return an error
-// if there's a filtered field that is not present in the struct and if the field and filter types are not
-// compatible
-func sanitizeFilter(filter squirrel.Sqlizer, fields map[string]fieldInfo) (squirrel.Sqlizer, error) {
-	switch filter := filter.(type) {
-	case squirrel.And:
-		ret, err := sanitizeCond(filter, fields)
-		return squirrel.And(ret), err
-	case squirrel.Or:
-		ret, err := sanitizeCond(filter, fields)
-		return squirrel.Or(ret), err
-	case squirrel.Eq:
-		ret, err := sanitizeMap(filter, fields)
-		return squirrel.Eq(ret), err
-	case squirrel.NotEq:
-		ret, err := sanitizeMap(filter, fields)
-		return squirrel.NotEq(ret), err
-	case squirrel.Gt:
-		ret, err := sanitizeMap(filter, fields)
-		return squirrel.Gt(ret), err
-	case squirrel.Lt:
-		ret, err := sanitizeMap(filter, fields)
-		return squirrel.Lt(ret), err
-	case squirrel.GtOrEq:
-		ret, err := sanitizeMap(filter, fields)
-		return squirrel.GtOrEq(ret), err
-	case squirrel.LtOrEq:
-		ret, err := sanitizeMap(filter, fields)
-		return squirrel.LtOrEq(ret), err
-	case squirrel.Like:
-		ret, err := sanitizeStringMap(filter, fields)
-		return squirrel.Like(ret), err
-	case squirrel.NotLike:
-		ret, err := sanitizeStringMap(filter, fields)
-		return squirrel.NotLike(ret), err
-	case squirrel.ILike:
-		ret, err := sanitizeStringMap(filter, fields)
-		return squirrel.ILike(ret), err
-	case squirrel.NotILike:
-		ret, err := sanitizeStringMap(filter, fields)
-		return squirrel.NotILike(ret), err
+// sanitizeValue returns nil if squirrel would render value as NULL, which is the case for nil pointers and
+// driver.Valuers that return nil. Otherwise, value is returned with any pointers dereferenced and
+// driver.Valuers replaced with their driver value
+func sanitizeValue(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	val, ok, err := comparableValue(reflect.ValueOf(value))
+	if err != nil || !ok {
+		return nil, err
+	}
+	return val.Interface(), nil
+}
+
+// indirect dereferences v until it's no longer a pointer. If a nil pointer is encountered, ok is false
+func indirect(v reflect.Value) (value reflect.Value, ok bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// isValuer reports whether t or a pointer to t implements driver.Valuer
+func isValuer(t reflect.Type) bool {
+	return t.Implements(valuerType) || reflect.PtrTo(t).Implements(valuerType)
+}
+
+// nullable reports whether fields of type t can hold SQL NULL
+func nullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
 	default:
-		return filter, nil
+		return isValuer(t)
 	}
 }
 
-func sanitizeCond(filters []squirrel.Sqlizer, fields map[string]fieldInfo) ([]squirrel.Sqlizer, error) {
-	output := make([]squirrel.Sqlizer, 0, len(filters))
-	for _, filter := range filters {
-		filter, err := sanitizeFilter(filter, fields)
-		if err != nil {
-			return nil, err
+// comparableValue returns the value of v that is used for comparisons. Pointers and interfaces are
+// dereferenced, and driver.Valuers are replaced with their driver value. If a nil pointer or interface is
+// found or a driver.Valuer returns nil, the value is SQL NULL and ok is false
+func comparableValue(v reflect.Value) (value reflect.Value, ok bool, err error) {
+	for {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return reflect.Value{}, false, nil
+		}
+		var valuer driver.Valuer
+		if v.Type().Implements(valuerType) {
+			valuer = v.Interface().(driver.Valuer)
+		} else if v.CanAddr() && v.Addr().Type().Implements(valuerType) {
+			valuer = v.Addr().Interface().(driver.Valuer)
+		}
+		if valuer != nil {
+			driverValue, err := valuer.Value()
+			if err != nil || driverValue == nil {
+				return reflect.Value{}, false, err
+			}
+			return reflect.ValueOf(driverValue), true, nil
+		}
+		if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+			return v, true, nil
 		}
-		output = append(output, filter)
+		v = v.Elem()
 	}
-	return output, nil
 }
 
-func sanitizeMap(filters map[string]interface{}, fields map[string]fieldInfo) (map[string]interface{}, error) {
-	output := make(map[string]interface{})
-	for name, value := range filters {
-		nameLower := strings.ToLower(name)
-		field, ok := fields[nameLower]
+// typesMatch reports whether a value of type valueType can be compared against a field of type fieldType.
+// Pointer fields are compared by the type they point to, and driver.Valuer fields by the type of their value
+func typesMatch(fieldType, valueType reflect.Type) bool {
+	if valueType == nil {
+		return false
+	}
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	if isValuer(fieldType) {
+		valueFieldType, ok := nullTypeValue(fieldType)
 		if !ok {
-			return nil, fmt.Errorf("struct has no field named '%v'", name)
+			// the type of the value is only known once it's retrieved
+			return isDriverValueType(valueType)
+		}
+		fieldType = valueFieldType
+	}
+	expectedKind := reducedKind(fieldType.Kind())
+	switch expectedKind {
+	case reflect.Int64, reflect.Uint64, reflect.Float64:
+		return expectedKind == reducedKind(valueType.Kind())
+	case reflect.Interface:
+		return valueType.Implements(fieldType)
+	default:
+		return fieldType == valueType
+	}
+}
+
+// nullTypeValue returns the type of the value held by types shaped like the sql.Null* types: structs made of
+// a value and a boolean Valid field
+func nullTypeValue(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || t.NumField() != 2 {
+		return nil, false
+	}
+	for i, valid := range []int{1, 0} {
+		if field := t.Field(valid); field.Name == "Valid" && field.Type.Kind() == reflect.Bool {
+			return t.Field(i).Type, true
+		}
+	}
+	return nil, false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// isDriverValueType reports whether t can be compared against a value returned from a driver.Valuer
+func isDriverValueType(t reflect.Type) bool {
+	switch reducedKind(t.Kind()) {
+	case reflect.Int64, reflect.Uint64, reflect.Float64, reflect.Bool, reflect.String:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	default:
+		return t == timeType
+	}
+}
+
+// isListType reports whether value would be rendered by squirrel as a list of values (e.g. for IN)
+func isListType(value interface{}) bool {
+	if driver.IsValue(value) {
+		return false
+	}
+	kind := reflect.ValueOf(value).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// valueList holds the values of a sanitized list filter (SQL IN)
+type valueList []interface{}
+
+// fieldValue returns the value of the field in item that is used for comparisons. If the field holds SQL
+// NULL or is part of a nil embedded struct, ok is false
+func fieldValue(item reflect.Value, field fieldInfo) (value reflect.Value, ok bool, err error) {
+	value, ok = fieldByIndex(item, field.Index)
+	if !ok {
+		return reflect.Value{}, false, nil
+	}
+	if (value.Kind() == reflect.Slice || value.Kind() == reflect.Map) && value.IsNil() {
+		return reflect.Value{}, false, nil
+	}
+	return comparableValue(value)
+}
+
+// matchesEq evaluates the SQL expression 'field = value', or 'field <> value' if negate is set, for a
+// sanitized value. nil values are compared with IS (NOT) NULL, and valueLists are compared with (NOT) IN.
+// As in SQL, comparisons against NULL never match. notNull should be false if the field holds NULL
+func matchesEq(fieldValue reflect.Value, notNull bool, value interface{}, negate bool) bool {
+	if value == nil {
+		return notNull == negate
+	}
+	list, ok := value.(valueList)
+	if !ok {
+		return notNull && valuesEqual(fieldValue, reflect.ValueOf(value)) != negate
+	}
+	if len(list) == 0 {
+		return negate
+	}
+	if !notNull {
+		return false
+	}
+	for _, elem := range list {
+		if elem == nil {
+			// 'field NOT IN (..., NULL)' is never true
+			if negate {
+				return false
+			}
+			continue
 		}
-		expectedKind := reducedKind(field.Type.Kind())
-		typesMatch := false
-		switch expectedKind {
-		case reflect.Int64, reflect.Uint64, reflect.Float64:
-			typesMatch = expectedKind == reducedKind(reflect.ValueOf(value).Kind())
-		default:
-			typesMatch = field.Type == reflect.ValueOf(value).Type()
+		if valuesEqual(fieldValue, reflect.ValueOf(elem)) {
+			return !negate
 		}
-		if !typesMatch {
-			return nil, fmt.Errorf("expected field '%v' to have type %v, got %v", name, field.Type, reflect.ValueOf(value).Type())
+	}
+	return negate
+}
+
+// valuesEqual compares numeric values by their reduced kind, so that differently sized numbers with the
+// same value are equal, and times with time.Time.Equal. All other values must be deeply equal
+func valuesEqual(v1, v2 reflect.Value) bool {
+	switch reducedKind(v1.Kind()) {
+	case reflect.Int64, reflect.Uint64, reflect.Float64:
+		return compareValues(v1, v2, opEQ)
+	case reflect.Struct:
+		if v1.Type() == timeType {
+			return compareValues(v1, v2, opEQ)
 		}
-		output[nameLower] = value
+		return reflect.DeepEqual(v1.Interface(), v2.Interface())
+	case reflect.String:
+		return v1.String() == v2.String()
+	case reflect.Bool:
+		return v1.Bool() == v2.Bool()
+	default:
+		return reflect.DeepEqual(v1.Interface(), v2.Interface())
 	}
-	return output, nil
 }
 
-func sanitizeStringMap(filters map[string]interface{}, fields map[string]fieldInfo) (map[string]interface{}, error) {
-	output := make(map[string]interface{})
-	for name, value := range filters {
-		nameLower := strings.ToLower(name)
-		_, ok := fields[nameLower]
+// sanitizeStringMap validates the expressions of a Like filter against the struct fields and compiles them. If
+// caseInsensitive is set, the compiled expressions ignore case
+func (f *Filterer) sanitizeStringMap(filters map[string]interface{}, fields map[string]fieldInfo, caseInsensitive bool) ([]likeCondition, error) {
+	output := make([]likeCondition, 0, len(filters))
+	for _, name := range sortedKeys(filters) {
+		value := filters[name]
+		field, ok := fields[f.normalizeName(name)]
 		if !ok {
 			return nil, fmt.Errorf("struct has no field named '%v'", name)
 		}
@@ -374,11 +687,29 @@ func sanitizeStringMap(filters map[string]interface{}, fields map[string]fieldIn
 			return nil, errors.New("expression must be a string")
 		}
 
-		output[nameLower] = value
+		reString := expressionToRegexp(fmt.Sprint(value))
+		if caseInsensitive {
+			reString = `(?i)` + reString
+		}
+		re, err := regexp.Compile(reString)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression for field '%v': %w", name, err)
+		}
+		output = append(output, likeCondition{field: field, re: re})
 	}
 	return output, nil
 }
 
+// sortedKeys returns the keys of a filter in sorted order, so filters are always evaluated in the same order
+func sortedKeys(filters map[string]interface{}) []string {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // reducedKind returns a simplified kind. Numeric kinds are reduced to their biggest representation, as those
 // are the forms easily obtainable through a reflect.Value
 func reducedKind(kind reflect.Kind) reflect.Kind {
@@ -395,27 +726,131 @@ func reducedKind(kind reflect.Kind) reflect.Kind {
 }
 
 type fieldInfo struct {
-	Index int
+	Index []int
 	Type  reflect.Type
+	// Optional is set for the fields of nested or embedded struct pointers, which are NULL if the pointer is nil
+	Optional bool
+}
+
+// cachedFields is the result of loading the fields of a type, as stored in a Filterer's cache
+type cachedFields struct {
+	fields map[string]fieldInfo
+	err    error
+}
+
+// getFields returns the filterable fields of the struct type t, keyed by their normalized name. The fields of
+// each type are only loaded once and then cached. The returned map must not be modified
+func (f *Filterer) getFields(t reflect.Type) (map[string]fieldInfo, error) {
+	if cached, ok := f.fieldCache.Load(t); ok {
+		c := cached.(cachedFields)
+		return c.fields, c.err
+	}
+	fields, err := f.loadFields(t)
+	f.fieldCache.Store(t, cachedFields{fields: fields, err: err})
+	return fields, err
 }
 
-func getFields(t reflect.Type) map[string]fieldInfo {
+// loadFields returns the filterable fields of the struct type t, keyed by their normalized name. Like sqlx, the
+// fields of embedded structs are promoted to the outer struct, and the fields of nested structs are named
+// by their path (e.g. "address.city"). When names collide, the least nested field is used. An error is
+// returned if multiple fields are equally nested and share the same name
+func (f *Filterer) loadFields(t reflect.Type) (map[string]fieldInfo, error) {
 	fields := make(map[string]fieldInfo)
+	ambiguous := make(map[string]bool)
+	f.addFields(fields, ambiguous, t, nil, "", false, map[reflect.Type]bool{t: true})
+
+	names := make([]string, 0, len(ambiguous))
+	for name, isAmbiguous := range ambiguous {
+		if isAmbiguous {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return nil, fmt.Errorf("struct has multiple fields named '%v'", strings.Join(names, "', '"))
+	}
+	return fields, nil
+}
+
+// addFields adds the fields of the struct type t to fields, where index and prefix are the index path and
+// name prefix of the struct within the outermost struct, and optional is set if the struct is reached through
+// a pointer. Names shared by fields of the same depth are recorded in ambiguous. visiting holds the struct
+// types currently being walked, to avoid looping over recursive types
+func (f *Filterer) addFields(fields map[string]fieldInfo, ambiguous map[string]bool, t reflect.Type, index []int, prefix string, optional bool, visiting map[reflect.Type]bool) {
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		if field.PkgPath != "" { // replace with !IsExported in go 1.17
+		exported := field.IsExported()
+		if !exported && !field.Anonymous {
+			continue
+		}
+		name, tagged, skip := f.parseTag(field.Tag)
+		if skip {
 			continue
 		}
+		fieldIndex := append(append(make([]int, 0, len(index)+1), index...), i)
 
-		// if the field has the tag, get the name from the tag
-		name := field.Name
-		if value, ok := field.Tag.Lookup(fieldNameTag); ok {
-			name = value
+		nestedType := field.Type
+		if nestedType.Kind() == reflect.Ptr {
+			nestedType = nestedType.Elem()
 		}
-		name = strings.ToLower(name)
-		fields[name] = fieldInfo{Index: i, Type: field.Type}
+		nested := nestedType.Kind() == reflect.Struct && nestedType != timeType && !isValuer(nestedType) && !visiting[nestedType]
+
+		if field.Anonymous && !tagged && nested {
+			visiting[nestedType] = true
+			f.addFields(fields, ambiguous, nestedType, fieldIndex, prefix, optional || field.Type.Kind() == reflect.Ptr, visiting)
+			delete(visiting, nestedType)
+			continue
+		}
+		if !exported {
+			continue
+		}
+		if !tagged {
+			name = f.nameMapper(field.Name)
+		}
+		name = prefix + f.normalizeName(name)
+		existing, ok := fields[name]
+		switch {
+		case !ok || len(fieldIndex) < len(existing.Index):
+			fields[name] = fieldInfo{Index: fieldIndex, Type: field.Type, Optional: optional}
+			ambiguous[name] = false
+		case len(fieldIndex) == len(existing.Index):
+			ambiguous[name] = true
+		}
+		if nested {
+			visiting[nestedType] = true
+			f.addFields(fields, ambiguous, nestedType, fieldIndex, name+".", optional || field.Type.Kind() == reflect.Ptr, visiting)
+			delete(visiting, nestedType)
+		}
+	}
+}
+
+// parseTag returns the column name given by the field's tag, ignoring any options following the name (e.g.
+// "name,omitempty"). tagged is false if the tag is missing or doesn't specify a name. skip is true if the
+// field is to be ignored, which is indicated with the name "-"
+func (f *Filterer) parseTag(tag reflect.StructTag) (name string, tagged, skip bool) {
+	value, ok := tag.Lookup(f.tagName)
+	if !ok {
+		return "", false, false
+	}
+	if i := strings.Index(value, ","); i >= 0 {
+		value = value[:i]
+	}
+	return value, value != "", value == "-"
+}
+
+// fieldByIndex returns the nested field of v corresponding to index. If a nil pointer to a struct is found
+// along the way, ok is false
+func fieldByIndex(v reflect.Value, index []int) (value reflect.Value, ok bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
 	}
-	return fields
+	return v, true
 }
 
 // getParamValues will check the parameters for the following properties:
@@ -425,38 +860,49 @@ func getFields(t reflect.Type) map[string]fieldInfo {
 // If all conditions are met, the reflect.Value of each is returned. The returned Value for
 // output will be addressable.
 func getParamValues(input, output interface{}) (reflect.Value, reflect.Value, error) {
-	if input == nil {
-		return reflect.Value{}, reflect.Value{}, errors.New("input is nil")
+	inputValue, err := getInputValue(input)
+	if err != nil {
+		return reflect.Value{}, reflect.Value{}, err
 	}
-	if output == nil {
-		return reflect.Value{}, reflect.Value{}, errors.New("output is nil")
+	outputValue, err := getOutputValue(output)
+	if err != nil {
+		return reflect.Value{}, reflect.Value{}, err
 	}
+	if outputValue.Type().Elem() != inputValue.Type().Elem() {
+		return reflect.Value{}, reflect.Value{}, errors.New("output slice type is not identical to the input ")
+	}
+	return inputValue, outputValue, nil
+}
 
-	// input checking
+func getInputValue(input interface{}) (reflect.Value, error) {
+	if input == nil {
+		return reflect.Value{}, errors.New("input is nil")
+	}
 	inputValue := reflect.ValueOf(input)
 	if inputValue.Kind() != reflect.Slice {
-		return reflect.Value{}, reflect.Value{}, errors.New("input is not a slice")
+		return reflect.Value{}, errors.New("input is not a slice")
 	}
-	inputSliceType := inputValue.Type().Elem()
 	// TODO: maybe support filtering if it's a type that implements some interface
-	if inputSliceType.Kind() != reflect.Struct {
-		return reflect.Value{}, reflect.Value{}, errors.New("input slice type is not filter-able")
+	if inputValue.Type().Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("input slice type is not filter-able")
 	}
+	return inputValue, nil
+}
 
-	// output checking
+// getOutputValue returns the slice output points to
+func getOutputValue(output interface{}) (reflect.Value, error) {
+	if output == nil {
+		return reflect.Value{}, errors.New("output is nil")
+	}
 	outputValue := reflect.ValueOf(output)
 	if outputValue.Kind() != reflect.Ptr {
-		return reflect.Value{}, reflect.Value{}, errors.New("output is not a valid reference")
+		return reflect.Value{}, errors.New("output is not a valid reference")
 	}
 	outputValue = outputValue.Elem()
 	if outputValue.Kind() != reflect.Slice {
-		return reflect.Value{}, reflect.Value{}, errors.New("output is not a reference to a slice")
-	}
-	outputSliceType := outputValue.Type().Elem()
-	if outputSliceType != inputSliceType {
-		return reflect.Value{}, reflect.Value{}, errors.New("output slice type is not identical to the input ")
+		return reflect.Value{}, errors.New("output is not a reference to a slice")
 	}
-	return inputValue, outputValue, nil
+	return outputValue, nil
 }
 
 func expressionToRegexp(input string) (output string) {
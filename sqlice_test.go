@@ -1,10 +1,13 @@
 package sqlice_test
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/pixelrazor/sqlice"
@@ -129,6 +132,96 @@ func TestFilter(t *testing.T) {
 			}{{B: []string{"a1", "a2"}}, {B: []string{"a1", "a4"}}},
 			filter: squirrel.NotEq{"bar": []string{"a1", "a3"}},
 		},
+		"Eq with slice": {
+			input:          []struct{ A int }{{A: 1}, {A: 2}, {A: 3}, {A: 4}},
+			output:         &[]struct{ A int }{},
+			expectedOutput: &[]struct{ A int }{{A: 1}, {A: 3}},
+			filter:         squirrel.Eq{"A": []int64{1, 3, 5}},
+		},
+		"Eq with empty slice": {
+			input:          []struct{ A int }{{A: 1}, {A: 2}, {A: 3}},
+			output:         &[]struct{ A int }{},
+			expectedOutput: &[]struct{ A int }{},
+			filter:         squirrel.Eq{"A": []int{}},
+		},
+		"Eq with array": {
+			input:          []struct{ A string }{{A: "a"}, {A: "b"}, {A: "c"}},
+			output:         &[]struct{ A string }{},
+			expectedOutput: &[]struct{ A string }{{A: "a"}, {A: "c"}},
+			filter:         squirrel.Eq{"A": [2]string{"c", "a"}},
+		},
+		"Eq with interface slice": {
+			input:          []struct{ A string }{{A: "a"}, {A: "b"}, {A: "c"}},
+			output:         &[]struct{ A string }{},
+			expectedOutput: &[]struct{ A string }{{A: "b"}},
+			filter:         squirrel.Eq{"A": []interface{}{"b"}},
+		},
+		"NotEq with slice": {
+			input:          []struct{ A int }{{A: 1}, {A: 2}, {A: 3}, {A: 4}},
+			output:         &[]struct{ A int }{},
+			expectedOutput: &[]struct{ A int }{{A: 2}, {A: 4}},
+			filter:         squirrel.NotEq{"A": []int{1, 3, 5}},
+		},
+		"NotEq with empty slice": {
+			input:          []struct{ A int }{{A: 1}, {A: 2}, {A: 3}},
+			output:         &[]struct{ A int }{},
+			expectedOutput: &[]struct{ A int }{{A: 1}, {A: 2}, {A: 3}},
+			filter:         squirrel.NotEq{"A": []int{}},
+		},
+		"Eq nil pointer": {
+			input:          []struct{ A *int }{{A: nil}, {A: new(int)}},
+			output:         &[]struct{ A *int }{},
+			expectedOutput: &[]struct{ A *int }{{A: nil}},
+			filter:         squirrel.Eq{"A": nil},
+		},
+		"Eq nil typed pointer": {
+			input:          []struct{ A *int }{{A: nil}, {A: new(int)}},
+			output:         &[]struct{ A *int }{},
+			expectedOutput: &[]struct{ A *int }{{A: nil}},
+			filter:         squirrel.Eq{"A": (*int)(nil)},
+		},
+		"Eq nil slice": {
+			input:          []struct{ A []int }{{A: nil}, {A: []int{}}},
+			output:         &[]struct{ A []int }{},
+			expectedOutput: &[]struct{ A []int }{{A: nil}},
+			filter:         squirrel.Eq{"A": nil},
+		},
+		"Eq nil interface": {
+			input:          []struct{ A interface{} }{{A: nil}, {A: 1}},
+			output:         &[]struct{ A interface{} }{},
+			expectedOutput: &[]struct{ A interface{} }{{A: nil}},
+			filter:         squirrel.Eq{"A": nil},
+		},
+		"Eq nil sql.NullString": {
+			input:          []struct{ A sql.NullString }{{A: sql.NullString{}}, {A: sql.NullString{String: "a", Valid: true}}},
+			output:         &[]struct{ A sql.NullString }{},
+			expectedOutput: &[]struct{ A sql.NullString }{{A: sql.NullString{}}},
+			filter:         squirrel.Eq{"A": nil},
+		},
+		"Eq invalid sql.NullString": {
+			input:          []struct{ A sql.NullString }{{A: sql.NullString{}}, {A: sql.NullString{String: "a", Valid: true}}},
+			output:         &[]struct{ A sql.NullString }{},
+			expectedOutput: &[]struct{ A sql.NullString }{{A: sql.NullString{}}},
+			filter:         squirrel.Eq{"A": sql.NullString{}},
+		},
+		"NotEq nil map": {
+			input:          []struct{ A map[string]int }{{A: nil}, {A: map[string]int{}}},
+			output:         &[]struct{ A map[string]int }{},
+			expectedOutput: &[]struct{ A map[string]int }{{A: map[string]int{}}},
+			filter:         squirrel.NotEq{"A": nil},
+		},
+		"NotEq excludes NULL": {
+			input:          []struct{ A []string }{{A: nil}, {A: []string{"a"}}, {A: []string{"b"}}},
+			output:         &[]struct{ A []string }{},
+			expectedOutput: &[]struct{ A []string }{{A: []string{"b"}}},
+			filter:         squirrel.NotEq{"A": []string{"a"}},
+		},
+		"NotEq list containing NULL": {
+			input:          []struct{ A *int }{{A: nil}, {A: new(int)}},
+			output:         &[]struct{ A *int }{},
+			expectedOutput: &[]struct{ A *int }{},
+			filter:         squirrel.NotEq{"A": []interface{}{nil}},
+		},
 		"Like with %": {
 			input: []struct {
 				B string `db:"bar"`
@@ -273,6 +366,48 @@ func TestFilter(t *testing.T) {
 			}{{B: "A1C"}, {B: "aciop"}},
 			filter: squirrel.NotLike{"bar": `a_c%`},
 		},
+		"tag with options": {
+			input: []struct {
+				A int `db:"foo,omitempty"`
+			}{{A: 1}, {A: 2}},
+			output: &[]struct {
+				A int `db:"foo,omitempty"`
+			}{},
+			expectedOutput: &[]struct {
+				A int `db:"foo,omitempty"`
+			}{{A: 2}},
+			filter: squirrel.Eq{"foo": 2},
+		},
+		"tag with only options": {
+			input: []struct {
+				A int `db:",omitempty"`
+			}{{A: 1}, {A: 2}},
+			output: &[]struct {
+				A int `db:",omitempty"`
+			}{},
+			expectedOutput: &[]struct {
+				A int `db:",omitempty"`
+			}{{A: 1}},
+			filter: squirrel.Eq{"a": 1},
+		},
+		"skipped field doesn't collide": {
+			input: []struct {
+				A int
+				B int `db:"-"`
+				C int `db:"b"`
+			}{{A: 1, B: 1, C: 2}, {A: 2, B: 2, C: 1}},
+			output: &[]struct {
+				A int
+				B int `db:"-"`
+				C int `db:"b"`
+			}{},
+			expectedOutput: &[]struct {
+				A int
+				B int `db:"-"`
+				C int `db:"b"`
+			}{{A: 2, B: 2, C: 1}},
+			filter: squirrel.Eq{"b": 1},
+		},
 		"ValueFilterer": {
 			input: []struct {
 				B []string `db:"bar"`
@@ -307,6 +442,273 @@ func TestFilter(t *testing.T) {
 	}
 }
 
+func TestFilter_PointerFields(t *testing.T) {
+	type FooBar struct {
+		A *int
+		B *string `db:"bar"`
+	}
+	intPtr := func(i int) *int { return &i }
+	stringPtr := func(s string) *string { return &s }
+	input := []FooBar{
+		{A: intPtr(1), B: stringPtr("one")},
+		{A: nil, B: nil},
+		{A: intPtr(2), B: stringPtr("two")},
+		{A: intPtr(3), B: stringPtr("three")},
+	}
+
+	tests := map[string]struct {
+		filter         squirrel.Sqlizer
+		expectedOutput []FooBar
+	}{
+		"Eq value":           {squirrel.Eq{"A": 2}, []FooBar{input[2]}},
+		"Eq pointer":         {squirrel.Eq{"A": intPtr(2)}, []FooBar{input[2]}},
+		"Eq list":            {squirrel.Eq{"bar": []string{"one", "three"}}, []FooBar{input[0], input[3]}},
+		"Eq list of pointer": {squirrel.Eq{"bar": []*string{stringPtr("two")}}, []FooBar{input[2]}},
+		"NotEq":              {squirrel.NotEq{"A": 2}, []FooBar{input[0], input[3]}},
+		"Gt":                 {squirrel.Gt{"A": 1}, []FooBar{input[2], input[3]}},
+		"LtOrEq pointer":     {squirrel.LtOrEq{"A": intPtr(2)}, []FooBar{input[0], input[2]}},
+		"Lt":                 {squirrel.Lt{"bar": "three"}, []FooBar{input[0]}},
+		"Like":               {squirrel.Like{"bar": "t%"}, []FooBar{input[2], input[3]}},
+		"NotLike":            {squirrel.NotLike{"bar": "t%"}, []FooBar{input[0]}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []FooBar
+			err := sqlice.Filter(input, &output, test.filter)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if !reflect.DeepEqual(output, test.expectedOutput) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedOutput, output)
+			}
+		})
+	}
+}
+
+type celsius struct {
+	degrees int
+}
+
+func (c celsius) Value() (driver.Value, error) {
+	return int64(c.degrees), nil
+}
+
+func TestFilter_Valuers(t *testing.T) {
+	type FooBar struct {
+		A sql.NullInt64
+		B sql.NullString `db:"bar"`
+		C celsius
+	}
+	input := []FooBar{
+		{A: sql.NullInt64{Int64: 1, Valid: true}, B: sql.NullString{String: "one", Valid: true}, C: celsius{10}},
+		{},
+		{A: sql.NullInt64{Int64: 2, Valid: true}, B: sql.NullString{String: "two", Valid: true}, C: celsius{20}},
+		{A: sql.NullInt64{Int64: 3, Valid: true}, B: sql.NullString{String: "three", Valid: true}, C: celsius{30}},
+	}
+
+	tests := map[string]struct {
+		filter         squirrel.Sqlizer
+		expectedOutput []FooBar
+	}{
+		"Eq value":       {squirrel.Eq{"A": 2}, []FooBar{input[2]}},
+		"Eq valuer":      {squirrel.Eq{"bar": sql.NullString{String: "one", Valid: true}}, []FooBar{input[0]}},
+		"Eq nil":         {squirrel.Eq{"A": nil}, []FooBar{input[1]}},
+		"Eq list":        {squirrel.Eq{"A": []int{1, 3}}, []FooBar{input[0], input[3]}},
+		"NotEq":          {squirrel.NotEq{"bar": "one"}, []FooBar{input[2], input[3]}},
+		"Gt":             {squirrel.Gt{"A": 1}, []FooBar{input[2], input[3]}},
+		"Lt":             {squirrel.Lt{"bar": "three"}, []FooBar{input[0]}},
+		"Like":           {squirrel.Like{"bar": "t%"}, []FooBar{input[2], input[3]}},
+		"custom valuer":  {squirrel.GtOrEq{"C": 20}, []FooBar{input[2], input[3]}},
+		"custom valuers": {squirrel.Eq{"C": celsius{10}}, []FooBar{input[0]}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []FooBar
+			err := sqlice.Filter(input, &output, test.filter)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if !reflect.DeepEqual(output, test.expectedOutput) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestFilter_Times(t *testing.T) {
+	type FooBar struct {
+		A time.Time
+		B sql.NullTime `db:"bar"`
+		C *time.Time
+	}
+	base := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	day := func(i int) time.Time { return base.AddDate(0, 0, i) }
+	dayPtr := func(i int) *time.Time { t := day(i); return &t }
+	input := []FooBar{
+		{A: day(0), B: sql.NullTime{Time: day(0), Valid: true}, C: dayPtr(0)},
+		{A: day(1), B: sql.NullTime{}, C: nil},
+		{A: day(2), B: sql.NullTime{Time: day(2), Valid: true}, C: dayPtr(2)},
+		{A: day(3), B: sql.NullTime{Time: day(3), Valid: true}, C: dayPtr(3)},
+	}
+
+	tests := map[string]struct {
+		filter         squirrel.Sqlizer
+		expectedOutput []FooBar
+	}{
+		"Gt":                {squirrel.Gt{"A": day(1)}, []FooBar{input[2], input[3]}},
+		"Lt":                {squirrel.Lt{"A": day(1)}, []FooBar{input[0]}},
+		"GtOrEq":            {squirrel.GtOrEq{"bar": day(2)}, []FooBar{input[2], input[3]}},
+		"LtOrEq":            {squirrel.LtOrEq{"C": day(2)}, []FooBar{input[0], input[2]}},
+		"range":             {squirrel.And{squirrel.GtOrEq{"A": day(1)}, squirrel.Lt{"A": day(3)}}, []FooBar{input[1], input[2]}},
+		"Eq other location": {squirrel.Eq{"A": day(2).In(time.FixedZone("UTC-5", -5*60*60))}, []FooBar{input[2]}},
+		"NotEq":             {squirrel.NotEq{"A": day(0).Local()}, []FooBar{input[1], input[2], input[3]}},
+		"Eq list":           {squirrel.Eq{"C": []time.Time{day(0), day(3)}}, []FooBar{input[0], input[3]}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output []FooBar
+			err := sqlice.Filter(input, &output, test.filter)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if !reflect.DeepEqual(output, test.expectedOutput) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedOutput, output)
+			}
+		})
+	}
+}
+
+type BaseModel struct {
+	ID        int
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type Address struct {
+	City    string
+	ZipCode string `db:"zip"`
+}
+
+// Record is the element type of the slices queried by the tests of Select, Update, Delete and the other query
+// functions
+type Record struct {
+	ID        int
+	Name      string
+	Nickname  sql.NullString
+	Status    string
+	Amount    float64
+	Discount  *int
+	Logins    uint `db:"login_count"`
+	Small     int8
+	Active    bool
+	CreatedAt time.Time `db:"created_at"`
+	Address   Address   `db:"addr"`
+}
+
+// records returns a new copy of the rows the tests query
+func records() []Record {
+	base := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	five, ten := 5, 10
+	return []Record{
+		{ID: 1, Name: "alice", Nickname: sql.NullString{String: "al", Valid: true}, Status: "paid", Amount: 10, Discount: &five, Active: true, CreatedAt: base.Add(time.Hour), Address: Address{City: "Springfield"}},
+		{ID: 2, Name: "bob", Status: "pending", Amount: 20, Logins: 3, Active: true, CreatedAt: base},
+		{ID: 3, Name: "alice", Status: "paid", Amount: 30, Discount: &ten, Logins: 1, CreatedAt: base.Add(time.Hour)},
+		{ID: 4, Name: "carol", Status: "paid", Amount: 5, Active: true, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: 5, Name: "bob", Nickname: sql.NullString{String: "bobby", Valid: true}, Status: "refunded", Amount: 15, Discount: &five, Logins: 2, Active: true, CreatedAt: base.Add(time.Hour)},
+	}
+}
+
+// recordIDs returns the IDs of the records in order
+func recordIDs(values []Record) []int {
+	ids := []int{}
+	for _, v := range values {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+func TestFilter_NestedFields(t *testing.T) {
+	type User struct {
+		BaseModel
+		Name    string
+		Address Address
+		Work    *Address `db:"office"`
+	}
+	type Shadow struct {
+		*BaseModel
+		ID string
+	}
+
+	users := []User{
+		{BaseModel: BaseModel{ID: 1}, Name: "a", Address: Address{City: "Springfield", ZipCode: "1"}},
+		{BaseModel: BaseModel{ID: 2}, Name: "b", Address: Address{City: "Shelbyville", ZipCode: "2"}, Work: &Address{City: "Springfield"}},
+		{BaseModel: BaseModel{ID: 3}, Name: "c", Address: Address{City: "Springfield", ZipCode: "3"}, Work: &Address{City: "Capital City"}},
+	}
+	shadows := []Shadow{
+		{BaseModel: &BaseModel{ID: 1}, ID: "one"},
+		{BaseModel: nil, ID: "two"},
+	}
+
+	tests := map[string]struct {
+		input, output  interface{}
+		filter         squirrel.Sqlizer
+		expectedOutput interface{}
+	}{
+		"embedded field": {
+			input:          users,
+			output:         &[]User{},
+			filter:         squirrel.Gt{"id": 1},
+			expectedOutput: &[]User{users[1], users[2]},
+		},
+		"nested field": {
+			input:          users,
+			output:         &[]User{},
+			filter:         squirrel.Eq{"Address.City": "Springfield"},
+			expectedOutput: &[]User{users[0], users[2]},
+		},
+		"nested tagged field": {
+			input:          users,
+			output:         &[]User{},
+			filter:         squirrel.Lt{"address.zip": "3"},
+			expectedOutput: &[]User{users[0], users[1]},
+		},
+		"nested pointer field": {
+			input:          users,
+			output:         &[]User{},
+			filter:         squirrel.NotEq{"office.city": "Springfield"},
+			expectedOutput: &[]User{users[2]},
+		},
+		"nested struct field": {
+			input:          users,
+			output:         &[]User{},
+			filter:         squirrel.Eq{"office": nil},
+			expectedOutput: &[]User{users[0]},
+		},
+		"shadowed field": {
+			input:          shadows,
+			output:         &[]Shadow{},
+			filter:         squirrel.Eq{"id": "two"},
+			expectedOutput: &[]Shadow{shadows[1]},
+		},
+		"nil embedded struct": {
+			input:          shadows,
+			output:         &[]Shadow{},
+			filter:         squirrel.Eq{"created_at": time.Time{}},
+			expectedOutput: &[]Shadow{shadows[0]},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := sqlice.Filter(test.input, test.output, test.filter)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if !reflect.DeepEqual(test.output, test.expectedOutput) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedOutput, test.output)
+			}
+		})
+	}
+}
+
 func TestFilter_ErrorConditions(t *testing.T) {
 	tests := map[string]struct {
 		input, output interface{}
@@ -351,6 +753,36 @@ func TestFilter_ErrorConditions(t *testing.T) {
 			output: &[]struct{ A, b int }{},
 			filter: squirrel.Eq{"b": 5},
 		},
+		"unexported nested field ignored": {
+			input:  []struct{ A struct{ b int } }{},
+			output: &[]struct{ A struct{ b int } }{},
+			filter: squirrel.Eq{"a.b": 5},
+		},
+		"skipped field": {
+			input: []struct {
+				A int `db:"-"`
+			}{},
+			output: &[]struct {
+				A int `db:"-"`
+			}{},
+			filter: squirrel.Eq{"-": 1},
+		},
+		"duplicate field names": {
+			input:  []struct{ ID, Id int }{},
+			output: &[]struct{ ID, Id int }{},
+			filter: squirrel.Eq{"id": 1},
+		},
+		"duplicate tag names": {
+			input: []struct {
+				A int `db:"foo"`
+				B int `db:"FOO"`
+			}{},
+			output: &[]struct {
+				A int `db:"foo"`
+				B int `db:"FOO"`
+			}{},
+			filter: squirrel.Eq{"A": 1},
+		},
 		"filter field wrong type 1": {
 			input:  []struct{ A int }{{A: 1}, {A: 2}, {A: 3}},
 			output: &[]struct{ A int }{},
@@ -366,6 +798,41 @@ func TestFilter_ErrorConditions(t *testing.T) {
 			output: &[]struct{ A []string }{},
 			filter: squirrel.Eq{"A": []int{}},
 		},
+		"filter list element wrong type": {
+			input:  []struct{ A int }{{A: 1}, {A: 2}, {A: 3}},
+			output: &[]struct{ A int }{},
+			filter: squirrel.Eq{"A": []interface{}{1, "two"}},
+		},
+		"list used with Gt": {
+			input:  []struct{ A int }{{A: 1}, {A: 2}, {A: 3}},
+			output: &[]struct{ A int }{},
+			filter: squirrel.Gt{"A": []int{1, 2}},
+		},
+		"nil for non-nullable field": {
+			input:  []struct{ A int }{{A: 1}, {A: 2}, {A: 3}},
+			output: &[]struct{ A int }{},
+			filter: squirrel.Eq{"A": nil},
+		},
+		"nil used with Lt": {
+			input:  []struct{ A *int }{},
+			output: &[]struct{ A *int }{},
+			filter: squirrel.Lt{"A": nil},
+		},
+		"filter pointer field wrong type": {
+			input:  []struct{ A *int }{},
+			output: &[]struct{ A *int }{},
+			filter: squirrel.Gt{"A": "1"},
+		},
+		"filter sql.Null field wrong type": {
+			input:  []struct{ A sql.NullInt64 }{},
+			output: &[]struct{ A sql.NullInt64 }{},
+			filter: squirrel.Eq{"A": "1"},
+		},
+		"filter valuer field wrong type": {
+			input:  []struct{ A celsius }{},
+			output: &[]struct{ A celsius }{},
+			filter: squirrel.Eq{"A": struct{}{}},
+		},
 		"filter field wrong type 4": {
 			input:  []struct{ A []string }{},
 			output: &[]struct{ A []string }{},
@@ -382,6 +849,50 @@ func TestFilter_ErrorConditions(t *testing.T) {
 	}
 }
 
+func TestFilterSlice(t *testing.T) {
+	type FooBar struct {
+		A int
+		B string `db:"bar"`
+	}
+	input := []FooBar{{A: 1, B: "one"}, {A: 2, B: "two"}, {A: 3, B: "three"}}
+
+	tests := map[string]struct {
+		filter         squirrel.Sqlizer
+		expectedOutput []FooBar
+	}{
+		"nil filter": {nil, input},
+		"Eq":         {squirrel.Eq{"bar": "two"}, []FooBar{input[1]}},
+		"Or":         {squirrel.Or{squirrel.Lt{"A": 2}, squirrel.Like{"bar": "th%"}}, []FooBar{input[0], input[2]}},
+		"no matches": {squirrel.Gt{"A": 3}, []FooBar{}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := sqlice.FilterSlice(input, test.filter)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if !reflect.DeepEqual(output, test.expectedOutput) {
+				t.Errorf("Expected '%v' got '%v'", test.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestFilterSlice_ErrorConditions(t *testing.T) {
+	t.Run("slice type not filter-able", func(t *testing.T) {
+		_, err := sqlice.FilterSlice([]string{"a"}, squirrel.Eq{"A": 3})
+		if err == nil {
+			t.Fatal("Expected an error, got nil")
+		}
+	})
+	t.Run("filter field not in struct", func(t *testing.T) {
+		_, err := sqlice.FilterSlice([]struct{ A int }{{A: 1}}, squirrel.Eq{"B": 3})
+		if err == nil {
+			t.Fatal("Expected an error, got nil")
+		}
+	})
+}
+
 func ExampleFilter() {
 	type FooBar struct {
 		A int
@@ -407,6 +918,25 @@ func ExampleFilter() {
 	// Output: [{2 b} {4 d} {3 c}]
 }
 
+func ExampleFilterSlice() {
+	type FooBar struct {
+		A int
+		B string `db:"bar"`
+	}
+	input := []FooBar{
+		{A: 2, B: "b"},
+		{A: 4, B: "d"},
+		{A: 1, B: "a"},
+	}
+
+	output, err := sqlice.FilterSlice(input, squirrel.Eq{"bar": []string{"a", "d"}})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(output)
+	// Output: [{4 d} {1 a}]
+}
+
 func ExampleValueFilterFunc() {
 	type FooBar struct {
 		Things []int
@@ -430,3 +960,59 @@ func ExampleValueFilterFunc() {
 	fmt.Println(output)
 	// Output: [{[1 2 3]} {[5 8 9]}]
 }
+
+type benchmarkModel struct {
+	BaseModel
+	Name    string
+	Email   string `db:"email_address"`
+	Age     int
+	Address Address
+}
+
+var benchmarkInput = []benchmarkModel{
+	{BaseModel: BaseModel{ID: 1}, Name: "a", Age: 20},
+	{BaseModel: BaseModel{ID: 2}, Name: "b", Age: 30},
+	{BaseModel: BaseModel{ID: 3}, Name: "c", Age: 40},
+	{BaseModel: BaseModel{ID: 4}, Name: "d", Age: 50},
+}
+
+var benchmarkFilter = squirrel.And{squirrel.Gt{"age": 25}, squirrel.NotEq{"name": "d"}}
+
+// BenchmarkFilter filters a small slice with the default Filterer, which has the fields of the type cached
+func BenchmarkFilter(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var output []benchmarkModel
+		if err := sqlice.Filter(benchmarkInput, &output, benchmarkFilter); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkFilter_Uncached filters a small slice with a new Filterer every time, so the fields of the type
+// must be loaded on every call
+func BenchmarkFilter_Uncached(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var output []benchmarkModel
+		if err := sqlice.NewFilterer().Filter(benchmarkInput, &output, benchmarkFilter); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPredicate_Apply filters a small slice with a compiled filter
+func BenchmarkPredicate_Apply(b *testing.B) {
+	pred, err := sqlice.Compile(benchmarkFilter, benchmarkModel{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var output []benchmarkModel
+		if err := pred.Apply(benchmarkInput, &output); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package sqlice
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lann/builder"
+)
+
+// assignment is a column = value clause of an UPDATE
+type assignment struct {
+	column string
+	value  exprNode
+}
+
+// rowSelection holds the clauses of an UPDATE or DELETE selecting the rows it changes
+type rowSelection struct {
+	// where is nil if the statement has no WHERE clause
+	where   exprNode
+	orderBy []orderNode
+	// limit is nil if the statement has no LIMIT clause
+	limit  *uint64
+	offset uint64
+}
+
+// Update applies the query's SET assignments to the elements of the slice matching its WHERE clause, modifying
+// them in place, and returns the number of updated elements like sql.Result.RowsAffected. Slice must be a
+// pointer to a slice of structs. Values are validated against the fields like Eq values, and expressions such
+// as "count + 1" are evaluated against each element before any of its fields are set. ORDER BY, LIMIT and OFFSET
+// restrict which of the matching elements are updated. Either every selected element is updated, or none are
+func Update(slice interface{}, query squirrel.UpdateBuilder) (int64, error) {
+	return defaultFilterer.Update(slice, query)
+}
+
+// Update applies the query's assignments to the matching elements of the slice in place. See the package level
+// Update for details
+func (f *Filterer) Update(slice interface{}, query squirrel.UpdateBuilder) (int64, error) {
+	sliceVal, err := getSliceValue(slice)
+	if err != nil {
+		return 0, fmt.Errorf("failed to validate params: %w", err)
+	}
+	assignments, selection, err := planUpdate(query)
+	if err != nil {
+		return 0, fmt.Errorf("unable to use query: %w", err)
+	}
+	fields, err := f.getFields(sliceVal.Type().Elem())
+	if err != nil {
+		return 0, fmt.Errorf("unable to use slice type: %w", err)
+	}
+
+	dests := make([]fieldInfo, len(assignments))
+	values := make([]evaluator, len(assignments))
+	for i, set := range assignments {
+		field, ok := fields[f.normalizeName(set.column)]
+		if !ok {
+			return 0, fmt.Errorf("unable to use query: struct has no field named '%v'", set.column)
+		}
+		if value, ok := set.value.(valueNode); ok {
+			if err := validateAssignment(set.column, field, value.value); err != nil {
+				return 0, fmt.Errorf("unable to use query: %w", err)
+			}
+		}
+		if values[i], err = f.compileNode(set.value, fields); err != nil {
+			return 0, fmt.Errorf("unable to use query: %w", err)
+		}
+		dests[i] = field
+	}
+	indexes, err := f.selectIndexes(sliceVal, selection, fields)
+	if err != nil {
+		return 0, err
+	}
+
+	// evaluate every assignment before setting any fields, so the elements are only updated if all succeed
+	updates := make([][]reflect.Value, len(indexes))
+	for i, index := range indexes {
+		item := sliceVal.Index(index)
+		updates[i] = make([]reflect.Value, len(assignments))
+		for j, eval := range values {
+			value, err := eval(item)
+			if err != nil {
+				return 0, fmt.Errorf("unable to get value for field '%v': %w", assignments[j].column, err)
+			}
+			update := reflect.New(dests[j].Type).Elem()
+			if err := assignValue(update, value); err != nil {
+				return 0, fmt.Errorf("unable to set field '%v': %w", assignments[j].column, err)
+			}
+			updates[i][j] = update
+		}
+	}
+	for i, index := range indexes {
+		item := sliceVal.Index(index)
+		for j, update := range updates[i] {
+			allocFieldByIndex(item, dests[j].Index).Set(update)
+		}
+	}
+	return int64(len(indexes)), nil
+}
+
+// validateAssignment checks that value can be assigned to the field, like sanitizeMap checks Eq values, and that
+// numbers fit in it
+func validateAssignment(column string, field fieldInfo, value interface{}) error {
+	if value == nil {
+		if !nullable(field.Type) {
+			return fmt.Errorf("field '%v' of type %v can not be NULL", column, field.Type)
+		}
+		return nil
+	}
+	if !typesMatch(field.Type, reflect.TypeOf(value)) {
+		return fmt.Errorf("expected field '%v' to have type %v, got %v", column, field.Type, reflect.TypeOf(value))
+	}
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	valueVal := reflect.Indirect(reflect.ValueOf(value))
+	if isNumberKind(fieldType.Kind()) && valueVal.IsValid() && isNumberKind(valueVal.Kind()) {
+		if err := checkNumberRange(valueVal, fieldType); err != nil {
+			return fmt.Errorf("unable to assign %v to field '%v' of type %v: %w", value, column, field.Type, err)
+		}
+	}
+	return nil
+}
+
+// planUpdate parses the UPDATE statement rendered by the builder
+func planUpdate(query squirrel.UpdateBuilder) ([]assignment, *rowSelection, error) {
+	if err := checkStatementClauses(query); err != nil {
+		return nil, nil, err
+	}
+	where, err := statementConditions(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	sql, args, err := builder.Delete(query, "WhereParts").(squirrel.UpdateBuilder).ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &parser{tokens: tokens, args: args}
+	if err := p.expectKeyword("UPDATE"); err != nil {
+		return nil, nil, err
+	}
+	table, alias, err := p.parseTableName()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := p.expectKeyword("SET"); err != nil {
+		return nil, nil, err
+	}
+	var assignments []assignment
+	for {
+		t := p.peek()
+		target, err := p.parseOperand()
+		if err != nil {
+			return nil, nil, err
+		}
+		column, ok := unqualifyColumns(target, []string{table, alias}).(columnNode)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected column at position %d, got '%v'", t.pos, t.text)
+		}
+		if err := p.expectSymbol("="); err != nil {
+			return nil, nil, err
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, nil, err
+		}
+		assignments = append(assignments, assignment{column: column.name, value: unqualifyColumns(value, []string{table, alias})})
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	selection, err := p.parseRowSelection(table, alias, where)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, nil, err
+	}
+	return assignments, selection, nil
+}
+
+// checkStatementClauses returns an error if the UPDATE or DELETE builder has prefixes or suffixes, which can't be
+// applied to slices
+func checkStatementClauses(query interface{}) error {
+	for _, clause := range []string{"Prefixes", "Suffixes"} {
+		if value, ok := builder.Get(query, clause); ok && reflect.ValueOf(value).Len() > 0 {
+			return fmt.Errorf("unsupported clause %v", clause)
+		}
+	}
+	return nil
+}
+
+// statementConditions returns the conditions of the WHERE clause of an UPDATE or DELETE builder, or nil if it has
+// none. They're taken from the builder's parts, so the values of filters like squirrel.Eq are validated like Filter
+// validates them
+func statementConditions(query interface{}) (exprNode, error) {
+	whereParts, _ := builder.Get(query, "WhereParts")
+	where, err := parseConditions(sqlizers(whereParts))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse where part: %w", err)
+	}
+	return where, nil
+}
+
+// parseRowSelection parses the ORDER BY, LIMIT and OFFSET clauses of an UPDATE or DELETE, which select the rows
+// matching where, removing the table's name and alias from the columns
+func (p *parser) parseRowSelection(table, alias string, where exprNode) (*rowSelection, error) {
+	plan := &selectPlan{where: where}
+	var err error
+	if _, ok := p.acceptKeyword("ORDER"); ok {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		if plan.orderBy, err = p.parseOrderTerms(); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := p.acceptKeyword("LIMIT"); ok {
+		n, err := p.parseCount()
+		if err != nil {
+			return nil, err
+		}
+		plan.limit = &n
+	}
+	if _, ok := p.acceptKeyword("OFFSET"); ok {
+		if plan.offset, err = p.parseCount(); err != nil {
+			return nil, err
+		}
+	}
+	plan.unqualify(table, alias)
+	return &rowSelection{where: plan.where, orderBy: plan.orderBy, limit: plan.limit, offset: plan.offset}, nil
+}
+
+// selectIndexes returns the indexes of the elements of sliceVal selected by the clauses, in the order given by
+// ORDER BY, or otherwise in the order of the slice
+func (f *Filterer) selectIndexes(sliceVal reflect.Value, selection *rowSelection, fields map[string]fieldInfo) ([]int, error) {
+	where := matchAlways
+	if selection.where != nil {
+		eval, err := f.compileNode(selection.where, fields)
+		if err != nil {
+			return nil, fmt.Errorf("unable to use query: %w", err)
+		}
+		where = evaluatorMatcher(eval)
+	}
+	var terms []orderTerm
+	if len(selection.orderBy) > 0 {
+		var err error
+		if terms, err = f.compileOrderBy(selection.orderBy, fields); err != nil {
+			return nil, fmt.Errorf("unable to use query: %w", err)
+		}
+	}
+
+	var indexes []int
+	for i := 0; i < sliceVal.Len(); i++ {
+		matches, err := where(sliceVal.Index(i))
+		if err != nil {
+			return nil, fmt.Errorf("unable to apply filter: %w", err)
+		}
+		if matches {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(terms) > 0 {
+		if err := sortIndexes(sliceVal, indexes, terms); err != nil {
+			return nil, err
+		}
+	}
+	start := selection.offset
+	if start > uint64(len(indexes)) {
+		start = uint64(len(indexes))
+	}
+	indexes = indexes[start:]
+	if selection.limit != nil && *selection.limit < uint64(len(indexes)) {
+		indexes = indexes[:*selection.limit]
+	}
+	return indexes, nil
+}
+
+// getSliceValue returns the slice of structs slice points to, for modifying it in place
+func getSliceValue(slice interface{}) (reflect.Value, error) {
+	if slice == nil {
+		return reflect.Value{}, errors.New("slice is nil")
+	}
+	sliceVal := reflect.ValueOf(slice)
+	if sliceVal.Kind() != reflect.Ptr || sliceVal.IsNil() {
+		return reflect.Value{}, errors.New("slice is not a valid reference")
+	}
+	sliceVal = sliceVal.Elem()
+	if sliceVal.Kind() != reflect.Slice {
+		return reflect.Value{}, errors.New("slice is not a reference to a slice")
+	}
+	if sliceVal.Type().Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("slice type is not filter-able")
+	}
+	return sliceVal, nil
+}
@@ -0,0 +1,174 @@
+package sqlice_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pixelrazor/sqlice"
+)
+
+func TestUpdate(t *testing.T) {
+	two := 2
+	tests := map[string]struct {
+		query            squirrel.UpdateBuilder
+		expectedAffected int64
+		expected         func(rows []Record)
+	}{
+		"set": {
+			query:            squirrel.Update("users").Set("name", "dave").Where(squirrel.Eq{"id": 2}),
+			expectedAffected: 1,
+			expected:         func(rows []Record) { rows[1].Name = "dave" },
+		},
+		"set map": {
+			query:            squirrel.Update("users").SetMap(map[string]interface{}{"name": "x", "amount": 0.5}).Where("id > ?", 3),
+			expectedAffected: 2,
+			expected: func(rows []Record) {
+				rows[3].Name, rows[3].Amount = "x", 0.5
+				rows[4].Name, rows[4].Amount = "x", 0.5
+			},
+		},
+		"no where": {
+			query:            squirrel.Update("users").Set("amount", 0.0),
+			expectedAffected: 5,
+			expected: func(rows []Record) {
+				for i := range rows {
+					rows[i].Amount = 0
+				}
+			},
+		},
+		"no matches": {
+			query:            squirrel.Update("users").Set("name", "x").Where(squirrel.Eq{"id": 6}),
+			expectedAffected: 0,
+			expected:         func(rows []Record) {},
+		},
+		"expression": {
+			query:            squirrel.Update("users").Set("login_count", squirrel.Expr("login_count + 1")).Set("name", squirrel.Expr("name || '!'")),
+			expectedAffected: 5,
+			expected: func(rows []Record) {
+				for i := range rows {
+					rows[i].Logins++
+					rows[i].Name += "!"
+				}
+			},
+		},
+		"expression uses old values": {
+			query:            squirrel.Update("users").Set("id", squirrel.Expr("id * 10")).Set("amount", squirrel.Expr("id + 0.5")).Where("id = 1"),
+			expectedAffected: 1,
+			expected:         func(rows []Record) { rows[0].ID, rows[0].Amount = 10, 1.5 },
+		},
+		"set null": {
+			query:            squirrel.Update("users").Set("discount", nil).Where("discount IS NOT NULL"),
+			expectedAffected: 3,
+			expected:         func(rows []Record) { rows[0].Discount, rows[2].Discount, rows[4].Discount = nil, nil, nil },
+		},
+		"narrowing": {
+			query:            squirrel.Update("users").Set("small", 100).Set("id", squirrel.Expr("amount / 4")).Where("id = 2"),
+			expectedAffected: 1,
+			expected:         func(rows []Record) { rows[1].Small, rows[1].ID = 100, 5 },
+		},
+		"set pointer": {
+			query:            squirrel.Update("users").Set("discount", &two).Where(squirrel.Eq{"id": 2}),
+			expectedAffected: 1,
+			expected:         func(rows []Record) { rows[1].Discount = &two },
+		},
+		"set pointer from value": {
+			query:            squirrel.Update("users").Set("discount", 2).Where(squirrel.Eq{"id": 2}),
+			expectedAffected: 1,
+			expected:         func(rows []Record) { rows[1].Discount = &two },
+		},
+		"where filters": {
+			query:            squirrel.Update("users u").Set("name", "x").Where(squirrel.And{squirrel.Eq{"u.discount": 5}, squirrel.Gt{"amount": 12.0}}),
+			expectedAffected: 1,
+			expected:         func(rows []Record) { rows[4].Name = "x" },
+		},
+		"qualified columns": {
+			query:            squirrel.Update("users u").Set("u.name", "x").Where("u.id = 3"),
+			expectedAffected: 1,
+			expected:         func(rows []Record) { rows[2].Name = "x" },
+		},
+		"order by and limit": {
+			query:            squirrel.Update("users").Set("name", "top").OrderBy("amount DESC").Limit(2),
+			expectedAffected: 2,
+			expected:         func(rows []Record) { rows[1].Name, rows[2].Name = "top", "top" },
+		},
+		"offset": {
+			query:            squirrel.Update("users").Set("name", "x").OrderBy("id").Limit(1).Offset(1),
+			expectedAffected: 1,
+			expected:         func(rows []Record) { rows[1].Name = "x" },
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rows := records()
+			expected := records()
+			test.expected(expected)
+			affected, err := sqlice.Update(&rows, test.query)
+			if err != nil {
+				t.Fatal("Expected no error, got:", err)
+			}
+			if affected != test.expectedAffected {
+				t.Errorf("Expected '%v' got '%v'", test.expectedAffected, affected)
+			}
+			if !reflect.DeepEqual(rows, expected) {
+				t.Errorf("Expected '%v' got '%v'", expected, rows)
+			}
+		})
+	}
+}
+
+func TestUpdate_ErrorConditions(t *testing.T) {
+	rows := records()
+	tests := map[string]struct {
+		slice interface{}
+		query squirrel.UpdateBuilder
+	}{
+		"nil slice":         {slice: nil, query: squirrel.Update("users").Set("name", "x")},
+		"non pointer slice": {slice: rows, query: squirrel.Update("users").Set("name", "x")},
+		"non slice":         {slice: &rows[0], query: squirrel.Update("users").Set("name", "x")},
+		"non struct slice":  {slice: &[]int{1}, query: squirrel.Update("users").Set("name", "x")},
+		"no assignments":    {slice: &rows, query: squirrel.Update("users")},
+		"unknown column":    {slice: &rows, query: squirrel.Update("users").Set("foo", 1)},
+		"wrong type":        {slice: &rows, query: squirrel.Update("users").Set("name", 1)},
+		"non nullable null": {slice: &rows, query: squirrel.Update("users").Set("name", nil)},
+		"unknown where":     {slice: &rows, query: squirrel.Update("users").Set("name", "x").Where("foo = 1")},
+		"where type":        {slice: &rows, query: squirrel.Update("users").Set("name", "x").Where(squirrel.Eq{"name": 1})},
+		"qualified target":  {slice: &rows, query: squirrel.Update("users").Set("other.name", "x")},
+		"invalid target":    {slice: &rows, query: squirrel.Update("users").Set("1", "x")},
+		"prefix":            {slice: &rows, query: squirrel.Update("users").Prefix("WITH x AS (SELECT 1)").Set("name", "x")},
+		"suffix":            {slice: &rows, query: squirrel.Update("users").Set("name", "x").Suffix("RETURNING id")},
+		"evaluation error":  {slice: &rows, query: squirrel.Update("users").Set("id", squirrel.Expr("id / 0"))},
+		"literal overflow":  {slice: &rows, query: squirrel.Update("users").Set("small", 300)},
+		"result overflow":   {slice: &rows, query: squirrel.Update("users").Set("small", squirrel.Expr("id * 100"))},
+		"negative unsigned": {slice: &rows, query: squirrel.Update("users").Set("login_count", squirrel.Expr("login_count - 2"))},
+		"result type":       {slice: &rows, query: squirrel.Update("users").Set("id", squirrel.Expr("name"))},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := sqlice.Update(test.slice, test.query)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+			if !reflect.DeepEqual(rows, records()) {
+				t.Errorf("Expected '%v' got '%v'", records(), rows)
+			}
+		})
+	}
+}
+
+func ExampleUpdate() {
+	type FooBar struct {
+		Name string
+		Age  int
+	}
+	people := []FooBar{{Name: "alice", Age: 17}, {Name: "bob", Age: 25}, {Name: "carol", Age: 42}}
+
+	affected, err := sqlice.Update(&people, squirrel.Update("people").Set("age", squirrel.Expr("age + 1")).Where("age > ?", 18))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(affected, people)
+	// Output:
+	// 2 [{alice 17} {bob 26} {carol 43}]
+}